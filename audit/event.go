@@ -0,0 +1,180 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"reflect"
+	"strings"
+	"time"
+
+	"rdpctl/model"
+	"rdpctl/security"
+)
+
+// Event types.
+const (
+	EventUnlock       = "unlock"
+	EventUnlockFailed = "unlock-failed"
+	EventConnect      = "connect"
+	EventAdd          = "add"
+	EventEdit         = "edit"
+	EventDelete       = "delete"
+	EventKeyfile      = "keyfile" // Keyfile requirement added or removed
+	EventPurge        = "purge"   // Deleted connection removed from the trash for good
+)
+
+// Event is a single audit log entry.
+type Event struct {
+	Time            time.Time     `json:"time"`
+	Type            string        `json:"type"`
+	User            string        `json:"user"`                     // Local OS account that ran rdpctl
+	Hostname        string        `json:"hostname"`                 // Machine rdpctl ran on
+	ConnectionID    string        `json:"connectionId,omitempty"`   // Connection the event concerns
+	ConnectionName  string        `json:"connectionName,omitempty"` // Name of the connection at the time
+	Host            string        `json:"host,omitempty"`           // RDP host of the connection
+	Launcher        string        `json:"launcher,omitempty"`       // Client used for connects
+	DurationSeconds float64       `json:"durationSeconds,omitempty"`
+	ExitStatus      *int          `json:"exitStatus,omitempty"`
+	Changes         []FieldChange `json:"changes,omitempty"` // Field-level diff for edits
+	Detail          string        `json:"detail,omitempty"`
+}
+
+// FieldChange describes one changed field of a connection. Secret values are masked.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// secretMask replaces secret values in field diffs.
+const secretMask = "********"
+
+// secretFields are connection fields (by JSON name) whose values are never written to the log.
+var secretFields = map[string]bool{
+	"password": true,
+	"totp":     true,
+}
+
+// ignoredFields change on every edit or connect and are left out of diffs.
+var ignoredFields = map[string]bool{
+	"updatedAt":       true,
+	"lastConnectedAt": true,
+	"connectCount":    true,
+	"frequencyScore":  true,
+}
+
+// NewEvent returns an event of the given type stamped with the current time, user and machine.
+func NewEvent(eventType string) Event {
+	e := Event{Time: time.Now(), Type: eventType}
+	if u, err := user.Current(); err == nil {
+		e.User = u.Username
+	}
+	if h, err := os.Hostname(); err == nil {
+		e.Hostname = h
+	}
+	return e
+}
+
+// ForConnection returns a copy of e describing the connection c.
+func (e Event) ForConnection(c *model.Connection) Event {
+	e.ConnectionID = c.ID
+	e.ConnectionName = c.Name
+	e.Host = c.Host
+	return e
+}
+
+// Diff returns the field-level differences between two versions of a connection.
+func Diff(old, new *model.Connection) []FieldChange {
+	var changes []FieldChange
+
+	ov := reflect.ValueOf(*old)
+	nv := reflect.ValueOf(*new)
+	t := ov.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" || ignoredFields[name] {
+			continue
+		}
+
+		o := ov.Field(i).Interface()
+		n := nv.Field(i).Interface()
+		if reflect.DeepEqual(o, n) {
+			continue
+		}
+
+		change := FieldChange{Field: name, Old: formatValue(ov.Field(i)), New: formatValue(nv.Field(i))}
+		if secretFields[name] {
+			change.Old, change.New = maskSecret(change.Old), maskSecret(change.New)
+		}
+		if name == "extraArgs" {
+			// Client arguments can carry credentials, such as /p: or /gp:
+			change.Old = strings.Join(security.MaskArgs(old.ExtraArgs), ", ")
+			change.New = strings.Join(security.MaskArgs(new.ExtraArgs), ", ")
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// ChangeEvents compares the connections before and after an operation and returns
+// add, edit and delete events for every difference.
+func ChangeEvents(before, after []model.Connection) []Event {
+	var events []Event
+
+	old := make(map[string]*model.Connection, len(before))
+	for i := range before {
+		old[before[i].ID] = &before[i]
+	}
+
+	seen := make(map[string]bool, len(after))
+	for i := range after {
+		c := &after[i]
+		seen[c.ID] = true
+
+		prev, ok := old[c.ID]
+		if !ok {
+			events = append(events, NewEvent(EventAdd).ForConnection(c))
+			continue
+		}
+		if changes := Diff(prev, c); len(changes) > 0 {
+			e := NewEvent(EventEdit).ForConnection(c)
+			e.Changes = changes
+			events = append(events, e)
+		}
+	}
+
+	for i := range before {
+		if !seen[before[i].ID] {
+			events = append(events, NewEvent(EventDelete).ForConnection(&before[i]))
+		}
+	}
+
+	return events
+}
+
+func formatValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return ""
+		}
+		return formatValue(v.Elem())
+	case reflect.Slice:
+		parts := make([]string, v.Len())
+		for i := range parts {
+			parts[i] = fmt.Sprint(v.Index(i).Interface())
+		}
+		return strings.Join(parts, ", ")
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+func maskSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	return secretMask
+}
@@ -0,0 +1,317 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/nacl/box"
+
+	"rdpctl/model"
+)
+
+const (
+	logFormat  = "rdpctl-audit"
+	logVersion = 1
+)
+
+// ErrNotInitialized is returned when appending to a log that has not been created yet.
+var ErrNotInitialized = errors.New("audit log has not been initialized")
+
+// header is the first line of the log. It carries the public key entries are sealed to,
+// so events such as failed unlocks can be written without the vault's private key.
+type header struct {
+	Format    string `json:"format"`
+	Version   int    `json:"version"`
+	PublicKey []byte `json:"publicKey"`
+}
+
+// record is one line of the log after the header. Each record's hash covers the previous
+// record's hash, so editing or removing an entry breaks the chain.
+type record struct {
+	Seq  int    `json:"seq"`
+	Prev string `json:"prev"`
+	Data []byte `json:"data"` // Event sealed to the header's public key
+	Hash string `json:"hash"`
+}
+
+// Entry is a decrypted log record.
+type Entry struct {
+	Seq   int
+	Event Event
+}
+
+// PathFor returns the audit log path that belongs to the vault at vaultPath.
+func PathFor(vaultPath string) string {
+	return strings.TrimSuffix(vaultPath, ".enc") + ".audit"
+}
+
+// Init prepares the audit log for the vault. If the vault has no audit key yet, a key pair
+// is generated and a new log is started. It reports whether the vault was changed and must be saved.
+func Init(path string, v *model.Vault) (bool, error) {
+	if v.Audit != nil {
+		return false, nil
+	}
+
+	pub, priv, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return false, fmt.Errorf("failed to generate audit key: %w", err)
+	}
+
+	headerLine, err := json.Marshal(header{Format: logFormat, Version: logVersion, PublicKey: pub[:]})
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal audit header: %w", err)
+	}
+
+	// An existing log sealed to a key the vault does not hold is kept aside rather than overwritten.
+	if _, err := os.Stat(path); err == nil {
+		if err := os.Rename(path, path+".orphaned"); err != nil {
+			return false, fmt.Errorf("failed to move aside existing audit log: %w", err)
+		}
+	}
+
+	if err := os.WriteFile(path, append(headerLine, '\n'), 0600); err != nil {
+		return false, fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	v.Audit = &model.AuditState{PrivateKey: priv[:], HeadSeq: 0, HeadHash: genesisHash(headerLine)}
+	return true, nil
+}
+
+// Record appends e to the log and advances the vault's anchor to it. The caller must save the vault.
+func Record(path string, v *model.Vault, e Event) error {
+	if v.Audit == nil {
+		return ErrNotInitialized
+	}
+
+	rec, err := appendEvent(path, e)
+	if err != nil {
+		return err
+	}
+
+	v.Audit.HeadSeq = rec.Seq
+	v.Audit.HeadHash = rec.Hash
+	return nil
+}
+
+// RecordLocked appends e without access to the vault, for events that happen before it is unlocked.
+// Such entries are anchored the next time an event is recorded with the vault unlocked.
+func RecordLocked(path string, e Event) error {
+	_, err := appendEvent(path, e)
+	return err
+}
+
+func appendEvent(path string, e Event) (*record, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND, 0600)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotInitialized
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		return nil, fmt.Errorf("failed to lock audit log: %w", err)
+	}
+	defer syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	hdr, headerLine, records, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal audit event: %w", err)
+	}
+
+	var pub [32]byte
+	copy(pub[:], hdr.PublicKey)
+	sealed, err := box.SealAnonymous(nil, plaintext, &pub, rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt audit event: %w", err)
+	}
+
+	rec := record{Seq: 1, Prev: genesisHash(headerLine), Data: sealed}
+	if n := len(records); n > 0 {
+		rec.Seq = records[n-1].Seq + 1
+		rec.Prev = records[n-1].Hash
+	}
+	rec.Hash = recordHash(rec)
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal audit record: %w", err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return nil, fmt.Errorf("failed to append to audit log: %w", err)
+	}
+
+	return &rec, nil
+}
+
+// Read decrypts every entry of the log with the vault's audit key, without verifying the chain.
+func Read(path string, v *model.Vault) ([]Entry, error) {
+	if v.Audit == nil {
+		return nil, ErrNotInitialized
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	hdr, _, records, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	pub, priv := keys(v.Audit)
+	if !bytes.Equal(hdr.PublicKey, pub[:]) {
+		return nil, fmt.Errorf("audit log was not created for this vault")
+	}
+
+	entries := make([]Entry, 0, len(records))
+	for _, rec := range records {
+		plaintext, ok := box.OpenAnonymous(nil, rec.Data, pub, priv)
+		if !ok {
+			return nil, fmt.Errorf("failed to decrypt audit record %d", rec.Seq)
+		}
+		var e Event
+		if err := json.Unmarshal(plaintext, &e); err != nil {
+			return nil, fmt.Errorf("failed to parse audit record %d: %w", rec.Seq, err)
+		}
+		entries = append(entries, Entry{Seq: rec.Seq, Event: e})
+	}
+	return entries, nil
+}
+
+// VerifyResult summarizes a successful verification.
+type VerifyResult struct {
+	Records    int // Total records in the log
+	Unanchored int // Records appended after the vault's anchor, e.g. failed unlocks
+}
+
+// Verify checks that the log belongs to the vault, that every record decrypts and links to its
+// predecessor, and that the record the vault anchored is still present unchanged.
+func Verify(path string, v *model.Vault) (*VerifyResult, error) {
+	if v.Audit == nil {
+		return nil, ErrNotInitialized
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	hdr, headerLine, records, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	pub, priv := keys(v.Audit)
+	if !bytes.Equal(hdr.PublicKey, pub[:]) {
+		return nil, fmt.Errorf("audit log public key does not match the vault: the log was replaced")
+	}
+
+	prev := genesisHash(headerLine)
+	anchored := v.Audit.HeadSeq == 0 && v.Audit.HeadHash == prev
+	for i, rec := range records {
+		if rec.Seq != i+1 {
+			return nil, fmt.Errorf("record %d: expected sequence number %d: records were removed or reordered", rec.Seq, i+1)
+		}
+		if rec.Prev != prev {
+			return nil, fmt.Errorf("record %d: does not link to the previous record: the log was edited", rec.Seq)
+		}
+		if recordHash(rec) != rec.Hash {
+			return nil, fmt.Errorf("record %d: hash mismatch: the record was modified", rec.Seq)
+		}
+		if _, ok := box.OpenAnonymous(nil, rec.Data, pub, priv); !ok {
+			return nil, fmt.Errorf("record %d: failed to decrypt: the record was modified", rec.Seq)
+		}
+		if rec.Seq == v.Audit.HeadSeq {
+			if rec.Hash != v.Audit.HeadHash {
+				return nil, fmt.Errorf("record %d: does not match the hash recorded in the vault: the log was rewritten", rec.Seq)
+			}
+			anchored = true
+		}
+		prev = rec.Hash
+	}
+
+	if !anchored {
+		return nil, fmt.Errorf("log ends at record %d but the vault recorded %d entries: the log was truncated",
+			len(records), v.Audit.HeadSeq)
+	}
+
+	return &VerifyResult{Records: len(records), Unanchored: len(records) - v.Audit.HeadSeq}, nil
+}
+
+func parse(data []byte) (*header, []byte, []record, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	if !scanner.Scan() {
+		return nil, nil, nil, fmt.Errorf("audit log is empty")
+	}
+	headerLine := append([]byte(nil), scanner.Bytes()...)
+
+	var hdr header
+	if err := json.Unmarshal(headerLine, &hdr); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to parse audit log header: %w", err)
+	}
+	if hdr.Format != logFormat || hdr.Version != logVersion || len(hdr.PublicKey) != 32 {
+		return nil, nil, nil, fmt.Errorf("unsupported audit log format")
+	}
+
+	var records []record
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var rec record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to parse audit record %d: %w", len(records)+1, err)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	return &hdr, headerLine, records, nil
+}
+
+func genesisHash(headerLine []byte) string {
+	sum := sha256.Sum256(headerLine)
+	return hex.EncodeToString(sum[:])
+}
+
+func recordHash(rec record) string {
+	h := sha256.New()
+	h.Write([]byte(rec.Prev))
+	binary.Write(h, binary.BigEndian, uint64(rec.Seq))
+	h.Write(rec.Data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func keys(state *model.AuditState) (*[32]byte, *[32]byte) {
+	var pub, priv [32]byte
+	copy(priv[:], state.PrivateKey)
+	if derived, err := curve25519.X25519(priv[:], curve25519.Basepoint); err == nil {
+		copy(pub[:], derived)
+	}
+	return &pub, &priv
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"rdpctl/rdp"
+	"rdpctl/security"
+)
+
+// BreachList is the default --breaches path of `rdpctl audit-security`, set from the settings.
+var BreachList string
+
+// runAuditSecurity implements `rdpctl audit-security [--breaches PATH] [--output table|json]`.
+func runAuditSecurity(args []string, vaultPath string) error {
+	fs := flag.NewFlagSet("audit-security", flag.ContinueOnError)
+	breachPath := fs.String("breaches", BreachList, "offline Pwned Passwords SHA-1 file or k-anonymity range directory")
+	output := outputFlag(fs)
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return fmt.Errorf("usage: rdpctl audit-security [--breaches PATH] [--output table|json]")
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	var breaches *security.BreachList
+	if *breachPath != "" {
+		if breaches, err = security.OpenBreachList(*breachPath); err != nil {
+			return err
+		}
+	}
+
+	v, _, err := unlockVault(vaultPath)
+	if err != nil {
+		return err
+	}
+
+	report, err := security.Audit(v, rdp.BaseArgs, breaches)
+	if err != nil {
+		return err
+	}
+	if *output == "json" {
+		return writeJSON(report)
+	}
+
+	if len(report.Findings) > 0 {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "SEVERITY\tCONNECTION\tCHECK\tDETAIL")
+		for _, f := range report.Findings {
+			connection := f.Connection
+			if connection == "" {
+				connection = "(all)"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", f.Severity, connection, f.Check, f.Detail)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("Score: %d/100. %d high, %d medium and %d low findings across %d connections with %d stored passwords.\n",
+		report.Score, report.Count(security.High), report.Count(security.Medium), report.Count(security.Low),
+		report.Connections, report.Passwords)
+	if breaches == nil {
+		fmt.Println("Passwords were not checked against a breach list; pass --breaches or set breachList.")
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"rdpctl/audit"
+	"rdpctl/history"
+	"rdpctl/model"
+	"rdpctl/ui"
+	"rdpctl/vault"
+)
+
+// Run dispatches a non-interactive subcommand. args excludes the program name.
+func Run(args []string, vaultPath string, settingsPath string) error {
+	if len(args) == 0 {
+		printUsage()
+		return nil
+	}
+
+	switch args[0] {
+	case "list":
+		return runList(args[1:], vaultPath)
+	case "connect":
+		return runConnect(args[1:], vaultPath)
+	case "history":
+		return runHistory(args[1:], vaultPath)
+	case "restore":
+		return runRestore(args[1:], vaultPath)
+	case "trash":
+		return runTrash(args[1:], vaultPath)
+	case "explain":
+		return runExplain(args[1:], vaultPath)
+	case "var":
+		return runVar(args[1:], vaultPath)
+	case "wake":
+		return runWake(args[1:], vaultPath)
+	case "totp":
+		return runTOTP(args[1:], vaultPath)
+	case "genpass":
+		return runGenpass(args[1:])
+	case "sessions":
+		return runSessions(args[1:], vaultPath)
+	case "kill":
+		return runKill(args[1:], vaultPath)
+	case "workspace":
+		return runWorkspace(args[1:], vaultPath)
+	case "audit":
+		return runAudit(args[1:], vaultPath)
+	case "audit-security":
+		return runAuditSecurity(args[1:], vaultPath)
+	case "report":
+		return runReport(args[1:], vaultPath)
+	case "vaults":
+		return runVaults(args[1:], vaultPath)
+	case "copy":
+		return runCopy(args[1:], vaultPath)
+	case "import":
+		return runImport(args[1:], vaultPath)
+	case "export":
+		return runExport(args[1:], vaultPath)
+	case "keyfile":
+		return runKeyfile(args[1:], vaultPath)
+	case "config":
+		return runConfig(args[1:], settingsPath)
+	case "help", "-h", "--help":
+		printUsage()
+		return nil
+	default:
+		printUsage()
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+func printUsage() {
+	fmt.Println(`Usage: rdpctl [--vault PROFILE|PATH] [--keyfile PATH] [--password-stdin | --password-file PATH]
+              [--menu] [command] [arguments]
+
+Run without a command to start the full-screen interface, or the classic menu with --menu. --vault (or RDPCTL_VAULT) selects a
+named vault profile or a vault file; RDPCTL_CONFIG_DIR moves the configuration directory.
+--keyfile (or RDPCTL_KEYFILE) supplies the keyfile of a vault that requires one.
+--password-stdin and --password-file read the master password instead of prompting for it,
+as does the unlock.passwordCommand setting.
+
+Commands:
+  list [--sort recent|frequent|...] [--output table|json]
+                                        List connections (sort: recent, frequent, name, host)
+  connect <search> [--background] [--domain D] [--username U] [--gateway G] [--args A,B]
+                                        Connect to the best fuzzy match, or choose when ambiguous
+  explain <name> [--domain D] [--username U] [--gateway G] [--args A,B]
+                                        Show each effective setting of a host and where it came from
+  history <name> [--output table|json]  Show earlier versions of a host and what each edit changed
+  restore <name> --version N            Roll a host back to an earlier version
+  trash list | trash restore <name> | trash empty
+                                        List, restore or permanently delete deleted hosts
+  var list | var set <name> <value> | var unset <name>
+                                        Manage vault variables referenced as ${var:name}
+  wake <name> [--wait] [--timeout 2m]   Send a Wake-on-LAN packet to a host
+  totp <name> [--copy]                  Print the current TOTP code of a host, or copy it
+  genpass [--length N] [--words N] [--symbols=false] [--copy]
+                                        Generate a password or passphrase
+  sessions [--output table|json]        List RDP clients running in the background
+  kill <name>                           Terminate background sessions of a host
+  workspace list                        List workspaces
+  workspace open <name> [--stagger 2s]  Launch every host of a workspace in the background
+  audit [--type T] [--connection NAME] [--since DATE] [--until DATE] [--verify]
+                                        Show or verify the audit log
+  audit-security [--breaches PATH] [--output table|json]
+                                        Report weak, reused and breached passwords and risky options
+  report timesheet [--from DATE] [--to DATE] [--group NAME] [--csv FILE] [--detail]
+                                        Show time spent per group, or export sessions as CSV
+  report adjust <id> [--minutes N] [--note TEXT]
+                                        Correct or annotate a recorded session
+  vaults                                List vault profiles
+  copy <name>... --to <vault>           Copy hosts into another vault profile or vault file
+  import [--format kdbx|bitwarden|1pux|keepassxc-csv] [--db-keyfile PATH] <file>
+                                        Import RDP entries from a KeePass database or a
+                                        Bitwarden, 1Password or KeePassXC export
+  export --format kdbx [--force] <file> Write the vault to a new KeePass database
+  keyfile generate <path>               Write a new random keyfile
+  keyfile add <path> | keyfile remove   Require a keyfile to unlock the vault, or stop requiring one
+  config list | config get <key> | config set <key> <value> | config path
+                                        Show or change global settings in config.yaml
+  help                                  Show this help`)
+}
+
+// unlockVault unlocks an existing vault for a subcommand. Unlike the interactive menu,
+// subcommands never create a new vault.
+func unlockVault(vaultPath string) (*model.Vault, string, error) {
+	if _, err := os.Stat(vaultPath); os.IsNotExist(err) {
+		return nil, "", fmt.Errorf("no vault found at %s; run rdpctl without arguments to create one", vaultPath)
+	}
+	return ui.UnlockFlow(vaultPath)
+}
+
+// findConnection returns the connection whose name matches name (case-insensitive).
+func findConnection(v *model.Vault, name string) (*model.Connection, error) {
+	for i := range v.Connections {
+		if strings.EqualFold(v.Connections[i].Name, name) {
+			return &v.Connections[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no connection named %q", name)
+}
+
+// saveChanges records audit events and connection history for the differences between before and
+// the vault's current connections, then saves the vault.
+func saveChanges(vaultPath string, v *model.Vault, before []model.Connection, masterPassword string) error {
+	history.Record(v, before, time.Now())
+	for _, e := range audit.ChangeEvents(before, v.Connections) {
+		if err := audit.Record(audit.PathFor(vaultPath), v, e); err != nil {
+			fmt.Printf("Warning: failed to write audit log: %v\n", err)
+			break
+		}
+	}
+	return vault.SaveVault(vaultPath, v, masterPassword)
+}
+
+// splitList splits a comma-separated flag value, trimming whitespace and dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseArgs parses flags that may appear before or after positional arguments,
+// returning the positional arguments in order.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"rdpctl/config"
+)
+
+// runConfig implements `rdpctl config <list|get|set|path>`, managing the global configuration file.
+// It never needs the vault.
+func runConfig(args []string, settingsPath string) error {
+	usage := fmt.Errorf("usage: rdpctl config list | config get <key> | config set <key> <value> | config path")
+	if len(args) == 0 {
+		return usage
+	}
+
+	switch {
+	case args[0] == "list" && len(args) == 1:
+	case args[0] == "get" && len(args) == 2:
+	case args[0] == "set" && len(args) == 3:
+	case args[0] == "path" && len(args) == 1:
+		fmt.Println(settingsPath)
+		return nil
+	default:
+		return usage
+	}
+
+	s, err := config.LoadSettings(settingsPath)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+		defaults := config.DefaultSettings()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "KEY\tVALUE\tDEFAULT")
+		for _, key := range s.Keys() {
+			value, _ := s.Get(key)
+			def, _ := defaults.Get(key)
+			fmt.Fprintf(w, "%s\t%s\t%s\n", key, value, def)
+		}
+		return w.Flush()
+	case "get":
+		value, err := s.Get(args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Println(value)
+		return nil
+	}
+
+	if err := s.Set(args[1], args[2]); err != nil {
+		return err
+	}
+	return config.SaveSettings(settingsPath, s)
+}
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"rdpctl/fuzzy"
+	"rdpctl/ui"
+	"rdpctl/usage"
+)
+
+// maxConnectChoices is how many of the best matches `rdpctl connect` offers when a search is ambiguous.
+const maxConnectChoices = 10
+
+// runConnect implements `rdpctl connect <search> [--background] [--domain D] [--username U]
+// [--gateway G] [--args A,B]`. The search matches fuzzily like the connection selector; the best
+// match is used when it is clear, otherwise the user picks one. The flags override the settings
+// the connection inherits for this connect only.
+func runConnect(args []string, vaultPath string) error {
+	fs := flag.NewFlagSet("connect", flag.ContinueOnError)
+	background := fs.Bool("background", false, "start the RDP client in the background and return")
+	overrides := overrideFlags(fs)
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	query := strings.TrimSpace(strings.Join(positional, " "))
+	if query == "" {
+		return fmt.Errorf("usage: rdpctl connect <search> [--background] [--domain D] [--username U] [--gateway G] [--args A,B]")
+	}
+
+	v, masterPassword, err := unlockVault(vaultPath)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	results := fuzzy.Rank(usage.Sorted(v.Connections, usage.SortOrder(v.SortOrder), now), query, now)
+	if len(results) == 0 {
+		return fmt.Errorf("no connection matches %q", query)
+	}
+
+	best, ok := fuzzy.Best(results)
+	c := best.Connection
+	if !ok {
+		c, err = ui.SelectMatch(query, results[:min(len(results), maxConnectChoices)])
+		if err != nil {
+			return err
+		}
+	}
+
+	return ui.ConnectAndSave(v, c, *background, overrides(), masterPassword, vaultPath)
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"rdpctl/inherit"
+	"rdpctl/model"
+	"rdpctl/rdp"
+	"rdpctl/security"
+)
+
+// runExplain implements `rdpctl explain <name>`, showing every effective setting of a connection
+// and the layer it came from. Flags simulate connect-time overrides.
+func runExplain(args []string, vaultPath string) error {
+	fs := flag.NewFlagSet("explain", flag.ContinueOnError)
+	overrides := overrideFlags(fs)
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: rdpctl explain <name> [--domain D] [--username U] [--gateway G] [--args A,B]")
+	}
+
+	v, _, err := unlockVault(vaultPath)
+	if err != nil {
+		return err
+	}
+
+	c, err := findConnection(v, positional[0])
+	if err != nil {
+		return err
+	}
+
+	effective, settings := inherit.Effective(v, c, overrides())
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "SETTING\tVALUE\tSOURCE")
+	for _, s := range settings {
+		if s.Field == "extraArgs" {
+			// The winning layer's arguments are the effective ones; mask the credentials among them
+			s.Value = strings.Join(security.MaskArgs(effective.ExtraArgs), " ")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", s.Field, s.Value, s.Source)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	cmdArgs, err := rdp.BuildArgs(&effective, effective.Password, v.Variables)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\nCommand: %s %s\n", rdp.Launcher, rdp.SanitizeArgsForDisplay(cmdArgs))
+	return nil
+}
+
+// overrideFlags registers the connect-time override flags --domain, --username, --gateway and
+// --args on fs. The returned function gives their values once fs is parsed.
+func overrideFlags(fs *flag.FlagSet) func() *model.Defaults {
+	overrides := &model.Defaults{}
+	fs.StringVar(&overrides.Domain, "domain", "", "override the domain")
+	fs.StringVar(&overrides.Username, "username", "", "override the username")
+	fs.StringVar(&overrides.Gateway, "gateway", "", "override the RD Gateway")
+	extraArgs := fs.String("args", "", "override the extra xfreerdp arguments (comma-separated)")
+	return func() *model.Defaults {
+		overrides.ExtraArgs = splitList(*extraArgs)
+		return overrides
+	}
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"rdpctl/inherit"
+	"rdpctl/kdbx"
+	"rdpctl/model"
+	"rdpctl/ui"
+)
+
+// runExport implements `rdpctl export --format kdbx [--force] <file>`.
+func runExport(args []string, vaultPath string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", "", "format of the file: kdbx")
+	force := fs.Bool("force", false, "overwrite an existing file")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 || *format == "" {
+		return fmt.Errorf("usage: rdpctl export --format kdbx [--force] <file>")
+	}
+	if *format != "kdbx" {
+		return fmt.Errorf("unsupported export format %q", *format)
+	}
+
+	v, _, err := unlockVault(vaultPath)
+	if err != nil {
+		return err
+	}
+
+	// KeePass has no templates or defaults, so export what each connection effectively uses
+	conns := make([]model.Connection, 0, len(v.Connections))
+	for i := range v.Connections {
+		effective, _ := inherit.Effective(v, &v.Connections[i], nil)
+		conns = append(conns, effective)
+	}
+
+	password, err := ui.PromptPassword("Passphrase for the KeePass database")
+	if err != nil {
+		return err
+	}
+	confirm, err := ui.PromptPassword("Confirm passphrase")
+	if err != nil {
+		return err
+	}
+	if password != confirm {
+		return fmt.Errorf("passphrases do not match")
+	}
+	creds, err := kdbx.Credentials(password, "")
+	if err != nil {
+		return err
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if *force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	file, err := os.OpenFile(positional[0], flags, 0600)
+	if err != nil {
+		return err
+	}
+	if err := kdbx.Write(file, conns, creds); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Exported %d connections to %s.\n", len(conns), positional[0])
+	return nil
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"rdpctl/clipboard"
+	"rdpctl/passwords"
+)
+
+// runGenpass implements `rdpctl genpass`. The defaults come from the generator settings; the
+// password goes to stdout on its own so scripts can capture it.
+func runGenpass(args []string) error {
+	opts := passwords.DefaultOptions
+	fs := flag.NewFlagSet("genpass", flag.ContinueOnError)
+	fs.IntVar(&opts.Length, "length", opts.Length, "number of characters")
+	fs.BoolVar(&opts.Lower, "lower", opts.Lower, "include lower-case letters")
+	fs.BoolVar(&opts.Upper, "upper", opts.Upper, "include upper-case letters")
+	fs.BoolVar(&opts.Digits, "digits", opts.Digits, "include digits")
+	fs.BoolVar(&opts.Symbols, "symbols", opts.Symbols, "include symbols")
+	fs.IntVar(&opts.Words, "words", opts.Words, "generate a passphrase of this many words instead")
+	fs.StringVar(&opts.Separator, "separator", opts.Separator, "separator between passphrase words")
+	copyPassword := fs.Bool("copy", false, "copy the password to the clipboard instead of printing it")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return fmt.Errorf("usage: rdpctl genpass [--length N] [--lower=false] [--upper=false] [--digits=false] [--symbols=false] [--words N] [--separator S] [--copy]")
+	}
+
+	password, err := passwords.Generate(opts)
+	if err != nil {
+		return err
+	}
+	if *copyPassword {
+		if err := clipboard.Copy(password); err != nil {
+			return err
+		}
+		fmt.Printf("Password copied to the clipboard (%.0f bits).\n", passwords.Entropy(opts))
+		return nil
+	}
+	fmt.Println(password)
+	fmt.Fprintf(os.Stderr, "Strength: %.0f bits.\n", passwords.Entropy(opts))
+	return nil
+}
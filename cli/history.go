@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"rdpctl/audit"
+	"rdpctl/history"
+	"rdpctl/model"
+)
+
+// versionJSON is one prior version of a connection in `history --output json`.
+type versionJSON struct {
+	Version    int                 `json:"version"`
+	ReplacedAt time.Time           `json:"replacedAt"`
+	Changes    []audit.FieldChange `json:"changes"` // What the edit that replaced this version changed
+}
+
+// runHistory implements `rdpctl history <name>`, listing the kept prior versions of a connection
+// with what each later edit changed, newest first.
+func runHistory(args []string, vaultPath string) error {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	output := outputFlag(fs)
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: rdpctl history <name> [--output table|json]")
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	v, _, err := unlockVault(vaultPath)
+	if err != nil {
+		return err
+	}
+	c, err := findConnection(v, positional[0])
+	if err != nil {
+		return err
+	}
+
+	versions := history.Versions(v, c.ID)
+	entries := make([]versionJSON, len(versions))
+	for i, version := range versions {
+		// Each version is compared with the one that replaced it
+		next := c
+		if i+1 < len(versions) {
+			next = &versions[i+1].Connection
+		}
+		entries[len(versions)-1-i] = versionJSON{
+			Version:    version.Version,
+			ReplacedAt: version.ReplacedAt,
+			Changes:    audit.Diff(&version.Connection, next),
+		}
+	}
+
+	if *output == "json" {
+		return writeJSON(entries)
+	}
+
+	if len(entries) == 0 {
+		fmt.Printf("%s has no earlier versions.\n", c.Name)
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tREPLACED\tCHANGED BY THE NEXT VERSION")
+	for _, e := range entries {
+		version := fmt.Sprint(e.Version)
+		replaced := e.ReplacedAt.Local().Format("2006-01-02 15:04")
+		for _, change := range e.Changes {
+			fmt.Fprintf(w, "%s\t%s\t%s: %q -> %q\n", version, replaced, change.Field, change.Old, change.New)
+			version, replaced = "", ""
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("Current version: %d. Roll back with rdpctl restore %s --version N.\n", history.Current(v, c.ID), c.Name)
+	return nil
+}
+
+// runRestore implements `rdpctl restore <name> --version N`, rolling one connection back to a
+// prior version. The rest of the vault is left as it is.
+func runRestore(args []string, vaultPath string) error {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	version := fs.Int("version", 0, "version to restore, as listed by rdpctl history")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 || *version < 1 {
+		return fmt.Errorf("usage: rdpctl restore <name> --version N")
+	}
+
+	v, masterPassword, err := unlockVault(vaultPath)
+	if err != nil {
+		return err
+	}
+	c, err := findConnection(v, positional[0])
+	if err != nil {
+		return err
+	}
+
+	before := append([]model.Connection(nil), v.Connections...)
+	if err := history.Restore(v, c, *version, time.Now()); err != nil {
+		return err
+	}
+	if err := saveChanges(vaultPath, v, before, masterPassword); err != nil {
+		return err
+	}
+	fmt.Printf("Restored version %d of %s as version %d.\n", *version, c.Name, history.Current(v, c.ID))
+	return nil
+}
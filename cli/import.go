@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"rdpctl/importer"
+	"rdpctl/kdbx"
+	"rdpctl/model"
+	"rdpctl/transfer"
+	"rdpctl/ui"
+)
+
+// Import formats, guessed from the file extension when --format is not given.
+var importFormats = map[string]string{
+	".kdbx": "kdbx",
+	".json": "bitwarden",
+	".1pux": "1pux",
+	".csv":  "keepassxc-csv",
+}
+
+// runImport implements `rdpctl import [--format F] [--db-keyfile PATH] <file>`.
+func runImport(args []string, vaultPath string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := fs.String("format", "", "format of the file: kdbx, bitwarden, 1pux or keepassxc-csv")
+	dbKeyfile := fs.String("db-keyfile", "", "keyfile of the KeePass database")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: rdpctl import [--format kdbx|bitwarden|1pux|keepassxc-csv] [--db-keyfile PATH] <file>")
+	}
+	path := positional[0]
+	if *format == "" {
+		*format = importFormats[strings.ToLower(filepath.Ext(path))]
+		if *format == "" {
+			return fmt.Errorf("cannot tell the format of %s; use --format", path)
+		}
+	}
+
+	v, masterPassword, err := unlockVault(vaultPath)
+	if err != nil {
+		return err
+	}
+
+	var conns []model.Connection
+	var skipped []importer.Skipped
+	switch *format {
+	case "kdbx":
+		conns, skipped, err = readKDBX(path, *dbKeyfile)
+	case "bitwarden":
+		var data []byte
+		if data, err = os.ReadFile(path); err == nil {
+			conns, skipped, err = importer.ReadBitwarden(data, func() (string, error) {
+				return ui.PromptPassword("Export file password")
+			})
+		}
+	case "1pux":
+		conns, skipped, err = importer.Read1PUX(path)
+	case "keepassxc-csv":
+		var file *os.File
+		if file, err = os.Open(path); err == nil {
+			conns, skipped, err = importer.ReadKeePassXCCSV(file)
+			file.Close()
+		}
+	default:
+		return fmt.Errorf("unsupported import format %q", *format)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(skipped) > 0 {
+		fmt.Printf("Ignored %d entries that are not RDP connections.\n", len(skipped))
+	}
+	if len(conns) == 0 {
+		fmt.Println("No RDP connections found.")
+		return nil
+	}
+
+	chosen, err := ui.ReviewImport(v, conns)
+	if err != nil || len(chosen) == 0 {
+		return err
+	}
+
+	before := append([]model.Connection(nil), v.Connections...)
+	imported := 0
+	for _, c := range chosen {
+		if _, err := transfer.Add(v, c); err != nil {
+			var dup *transfer.DuplicateError
+			if !errors.As(err, &dup) {
+				return err
+			}
+			fmt.Printf("Skipped: %v\n", err)
+			continue
+		}
+		imported++
+	}
+	if imported == 0 {
+		return nil
+	}
+	if err := saveChanges(vaultPath, v, before, masterPassword); err != nil {
+		return err
+	}
+	fmt.Printf("%d connections imported.\n", imported)
+	return nil
+}
+
+// readKDBX asks for the password of a KeePass database and reads its RDP entries.
+func readKDBX(path, keyfile string) ([]model.Connection, []importer.Skipped, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	password, err := ui.PromptPassword("KeePass database password")
+	if err != nil {
+		return nil, nil, err
+	}
+	creds, err := kdbx.Credentials(password, keyfile)
+	if err != nil {
+		return nil, nil, err
+	}
+	return kdbx.Read(file, creds)
+}
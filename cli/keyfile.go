@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"fmt"
+
+	"rdpctl/audit"
+	"rdpctl/vault"
+)
+
+// runKeyfile implements `rdpctl keyfile <generate|add|remove>`, managing the keyfile a vault
+// requires in addition to its master password.
+func runKeyfile(args []string, vaultPath string) error {
+	usage := fmt.Errorf("usage: rdpctl keyfile generate <path> | keyfile add <path> | keyfile remove")
+	if len(args) == 0 {
+		return usage
+	}
+
+	switch {
+	case args[0] == "generate" && len(args) == 2:
+		if err := vault.GenerateKeyfile(args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("Keyfile written to %s. Keep a copy somewhere safe: a vault that requires it cannot be opened without it.\n", args[1])
+		return nil
+	case args[0] == "add" && len(args) == 2:
+	case args[0] == "remove" && len(args) == 1:
+	default:
+		return usage
+	}
+
+	v, masterPassword, err := unlockVault(vaultPath)
+	if err != nil {
+		return err
+	}
+
+	e := audit.NewEvent(audit.EventKeyfile)
+	if args[0] == "add" {
+		hash, err := vault.HashKeyfile(args[1])
+		if err != nil {
+			return err
+		}
+		v.KeyfileHash = hash
+		e.Detail = "keyfile required"
+	} else {
+		if v.KeyfileHash == nil {
+			return fmt.Errorf("this vault does not require a keyfile")
+		}
+		v.KeyfileHash = nil
+		e.Detail = "keyfile requirement removed"
+	}
+
+	if err := audit.Record(audit.PathFor(vaultPath), v, e); err != nil {
+		fmt.Printf("Warning: failed to write audit log: %v\n", err)
+	}
+	if err := vault.SaveVault(vaultPath, v, masterPassword); err != nil {
+		return err
+	}
+
+	if args[0] == "add" {
+		fmt.Printf("The vault now requires the keyfile %s as well as the master password.\n", args[1])
+		// Earlier backups open with the master password alone
+		removed, err := vault.RemoveBackups(vaultPath)
+		if removed > 0 {
+			fmt.Printf("Deleted %d backups of the vault that did not require the keyfile.\n", removed)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to delete vault backups that do not require the keyfile: %w", err)
+		}
+	} else {
+		fmt.Println("The vault no longer requires a keyfile.")
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"rdpctl/passwords"
+	"rdpctl/usage"
+)
+
+// listEntry is a connection as printed by `rdpctl list --output json`. It never includes the password.
+type listEntry struct {
+	Name            string    `json:"name"`
+	Host            string    `json:"host"`
+	Group           string    `json:"group,omitempty"`
+	Tags            []string  `json:"tags,omitempty"`
+	Domain          string    `json:"domain,omitempty"`
+	Username        string    `json:"username,omitempty"`
+	LastConnectedAt time.Time `json:"lastConnectedAt,omitzero"`
+	ConnectCount    int       `json:"connectCount"`
+
+	PasswordChangedAt time.Time `json:"passwordChangedAt,omitzero"`
+	PasswordStale     bool      `json:"passwordStale,omitempty"` // Older than the passwordMaxAgeDays setting
+}
+
+// runList implements `rdpctl list [--sort recent|frequent|name|host] [--output table|json]`.
+func runList(args []string, vaultPath string) error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	sortFlag := fs.String("sort", "", "sort by recent, frequent, name or host (default: the vault's sort order)")
+	output := outputFlag(fs)
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return fmt.Errorf("usage: rdpctl list [--sort recent|frequent|name|host] [--output table|json]")
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	v, _, err := unlockVault(vaultPath)
+	if err != nil {
+		return err
+	}
+
+	orderName := v.SortOrder
+	if *sortFlag != "" {
+		orderName = *sortFlag
+	}
+	order, err := usage.ParseSortOrder(orderName)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	sorted := usage.Sorted(v.Connections, order, now)
+	if *output == "json" {
+		entries := make([]listEntry, 0, len(sorted))
+		for _, c := range sorted {
+			entries = append(entries, listEntry{c.Name, c.Host, c.Group, c.Tags, c.Domain, c.Username, c.LastConnectedAt, c.ConnectCount,
+				c.PasswordChangedAt, passwords.IsStale(c, now)})
+		}
+		return writeJSON(entries)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tHOST\tGROUP\tDOMAIN\tUSERNAME\tLAST CONNECTED\tCONNECTS\tPASSWORD AGE")
+	stale := 0
+	for _, c := range sorted {
+		last := "never"
+		if !c.LastConnectedAt.IsZero() {
+			last = c.LastConnectedAt.Format("2006-01-02 15:04")
+		}
+		age := ""
+		if d := passwords.Age(c, now); d > 0 {
+			age = fmt.Sprintf("%dd", int(d.Hours()/24))
+		}
+		if passwords.IsStale(c, now) {
+			age += " (stale)"
+			stale++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t%s\n", c.Name, c.Host, c.Group, c.Domain, c.Username, last, c.ConnectCount, age)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if stale > 0 {
+		fmt.Printf("\nWarning: %d stored passwords are older than %d days.\n", stale, int(passwords.MaxAge.Hours()/24))
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// OutputFormat is the default output of listing commands, "table" or "json".
+// Commands that support it accept --output to override it.
+var OutputFormat = "table"
+
+// outputFlag registers --output on fs, defaulting to OutputFormat.
+func outputFlag(fs *flag.FlagSet) *string {
+	return fs.String("output", OutputFormat, "output format: table or json")
+}
+
+// checkOutput reports whether format is a supported output format.
+func checkOutput(format string) error {
+	if format != "table" && format != "json" {
+		return fmt.Errorf("unsupported output format %q (use table or json)", format)
+	}
+	return nil
+}
+
+// writeJSON prints v as indented JSON.
+func writeJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"rdpctl/timesheet"
+	"rdpctl/vault"
+)
+
+// runReport implements `rdpctl report <timesheet|adjust>`.
+func runReport(args []string, vaultPath string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: rdpctl report <timesheet|adjust> [arguments]")
+	}
+
+	switch args[0] {
+	case "timesheet":
+		return runTimesheet(args[1:], vaultPath)
+	case "adjust":
+		return runAdjust(args[1:], vaultPath)
+	default:
+		return fmt.Errorf("unknown report command %q", args[0])
+	}
+}
+
+// runTimesheet prints per-group totals of session time, optionally with every session, or exports CSV.
+func runTimesheet(args []string, vaultPath string) error {
+	fs := flag.NewFlagSet("report timesheet", flag.ContinueOnError)
+	from := fs.String("from", "", "first day to include (YYYY-MM-DD)")
+	to := fs.String("to", "", "last day to include (YYYY-MM-DD)")
+	group := fs.String("group", "", "only include sessions of this group")
+	csvPath := fs.String("csv", "", "write sessions as CSV to this file (- for standard output)")
+	detail := fs.Bool("detail", false, "list individual sessions below the totals")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return fmt.Errorf("usage: rdpctl report timesheet [--from DATE] [--to DATE] [--group NAME] [--csv FILE] [--detail]")
+	}
+
+	filter := timesheet.Filter{Group: *group}
+	if filter.From, err = parseDate(*from); err != nil {
+		return err
+	}
+	if filter.To, err = parseDate(*to); err != nil {
+		return err
+	}
+	if !filter.To.IsZero() {
+		filter.To = filter.To.AddDate(0, 0, 1) // --to is inclusive
+	}
+
+	v, _, err := unlockVault(vaultPath)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	sessions := timesheet.Select(v, filter)
+
+	if *csvPath != "" {
+		var out io.Writer = os.Stdout
+		if *csvPath != "-" {
+			file, err := os.Create(*csvPath)
+			if err != nil {
+				return fmt.Errorf("failed to create CSV file: %w", err)
+			}
+			defer file.Close()
+			out = file
+		}
+		if err := timesheet.WriteCSV(out, sessions, now); err != nil {
+			return err
+		}
+		if *csvPath != "-" {
+			fmt.Printf("Wrote %d sessions to %s\n", len(sessions), *csvPath)
+		}
+		return nil
+	}
+
+	if len(sessions) == 0 {
+		fmt.Println("No sessions in the selected period.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "GROUP\tSESSIONS\tHOURS")
+	var total time.Duration
+	for _, t := range timesheet.Totals(sessions, now) {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", t.Group, t.Sessions, timesheet.FormatHours(t.Duration))
+		total += t.Duration
+	}
+	fmt.Fprintf(w, "TOTAL\t%d\t%s\n", len(sessions), timesheet.FormatHours(total))
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	var unknown []string
+	for _, rec := range sessions {
+		if timesheet.NeedsAdjustment(rec) {
+			unknown = append(unknown, rec.ID[:8])
+		}
+	}
+	if len(unknown) > 0 {
+		fmt.Printf("\nWarning: %d sessions ended at an unknown time and count no hours: %s\n", len(unknown), strings.Join(unknown, ", "))
+		fmt.Println("Enter their length with rdpctl report adjust <id> --minutes N.")
+	}
+
+	if !*detail {
+		return nil
+	}
+
+	fmt.Println()
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "ID\tGROUP\tCONNECTION\tSTART\tEND\tADJUST\tHOURS\tNOTE")
+	for _, rec := range sessions {
+		end := "running"
+		if rec.EndUnknown {
+			end = "unknown"
+		} else if !rec.End.IsZero() {
+			end = rec.End.Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%+dm\t%s\t%s\n",
+			rec.ID[:8], rec.Group, rec.ConnectionName, rec.Start.Format("2006-01-02 15:04"), end,
+			rec.AdjustMinutes, timesheet.FormatHours(timesheet.Duration(rec, now)), rec.Note)
+	}
+	return w.Flush()
+}
+
+// runAdjust implements `rdpctl report adjust <session-id>`, correcting or annotating a session.
+func runAdjust(args []string, vaultPath string) error {
+	fs := flag.NewFlagSet("report adjust", flag.ContinueOnError)
+	minutes := fs.Int("minutes", 0, "minutes to add to (or, if negative, subtract from) the measured duration, or the length of a session whose end is unknown")
+	note := fs.String("note", "", "note to attach to the session")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: rdpctl report adjust <session-id> [--minutes N] [--note TEXT]")
+	}
+
+	v, masterPassword, err := unlockVault(vaultPath)
+	if err != nil {
+		return err
+	}
+
+	rec := timesheet.Find(v, positional[0])
+	if rec == nil {
+		return fmt.Errorf("no unique session with ID %q (see rdpctl report timesheet --detail)", positional[0])
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "minutes":
+			rec.AdjustMinutes = *minutes
+		case "note":
+			rec.Note = *note
+		}
+	})
+
+	if err := vault.SaveVault(vaultPath, v, masterPassword); err != nil {
+		return err
+	}
+	fmt.Printf("Session %s on %s now counts %s hours.\n",
+		rec.ID[:8], rec.ConnectionName, timesheet.FormatHours(timesheet.Duration(rec, time.Now())))
+	return nil
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"rdpctl/session"
+	"rdpctl/ui"
+)
+
+// sessionEntry is a background session as printed by `rdpctl sessions --output json`.
+type sessionEntry struct {
+	Name      string    `json:"name"`
+	Host      string    `json:"host"`
+	PID       int       `json:"pid"`
+	StartedAt time.Time `json:"startedAt"`
+	LogFile   string    `json:"logFile"`
+}
+
+// runSessions implements `rdpctl sessions [--output table|json]`.
+func runSessions(args []string, vaultPath string) error {
+	fs := flag.NewFlagSet("sessions", flag.ContinueOnError)
+	output := outputFlag(fs)
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return fmt.Errorf("usage: rdpctl sessions [--output table|json]")
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	v, _, err := unlockVault(vaultPath)
+	if err != nil {
+		return err
+	}
+
+	running, err := ui.RunningSessions(v)
+	if err != nil {
+		return err
+	}
+	if *output == "json" {
+		entries := make([]sessionEntry, 0, len(running))
+		for _, rs := range running {
+			entries = append(entries, sessionEntry{rs.Name, rs.Host, rs.PID, rs.StartedAt, rs.LogFile})
+		}
+		return writeJSON(entries)
+	}
+	if len(running) == 0 {
+		fmt.Println("No background sessions are running.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tHOST\tPID\tSTARTED\tUPTIME\tLOG")
+	for _, rs := range running {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n",
+			rs.Name, rs.Host, rs.PID, rs.StartedAt.Format("2006-01-02 15:04"),
+			time.Since(rs.StartedAt).Round(time.Second), rs.LogFile)
+	}
+	return w.Flush()
+}
+
+// runKill implements `rdpctl kill <name>`, terminating every background session of that connection.
+func runKill(args []string, vaultPath string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: rdpctl kill <name>")
+	}
+
+	v, _, err := unlockVault(vaultPath)
+	if err != nil {
+		return err
+	}
+
+	running, err := ui.RunningSessions(v)
+	if err != nil {
+		return err
+	}
+
+	killed := 0
+	for _, rs := range running {
+		if !strings.EqualFold(rs.Name, args[0]) {
+			continue
+		}
+		if err := session.Kill(rs.Session); err != nil {
+			return err
+		}
+		fmt.Printf("Sent terminate signal to %s (PID %d).\n", rs.Name, rs.PID)
+		killed++
+	}
+
+	if killed == 0 {
+		return fmt.Errorf("no running session for %q", args[0])
+	}
+	return nil
+}
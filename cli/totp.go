@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"rdpctl/clipboard"
+	"rdpctl/inherit"
+	"rdpctl/totp"
+)
+
+// runTOTP implements `rdpctl totp <name> [--copy]`. The code goes to stdout on its own so scripts
+// can capture it; how long it stays valid goes to stderr.
+func runTOTP(args []string, vaultPath string) error {
+	fs := flag.NewFlagSet("totp", flag.ContinueOnError)
+	copyCode := fs.Bool("copy", false, "copy the code to the clipboard instead of printing it")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: rdpctl totp <name> [--copy]")
+	}
+
+	v, _, err := unlockVault(vaultPath)
+	if err != nil {
+		return err
+	}
+
+	c, err := findConnection(v, positional[0])
+	if err != nil {
+		return err
+	}
+	// The key may come from a template or group shared by the hosts behind one gateway
+	effective, _ := inherit.Effective(v, c, nil)
+	if effective.TOTP == "" {
+		return fmt.Errorf("connection '%s' has no TOTP key", c.Name)
+	}
+	key, err := totp.Parse(effective.TOTP)
+	if err != nil {
+		return fmt.Errorf("invalid TOTP key for %s: %w", c.Name, err)
+	}
+
+	now := time.Now()
+	code := key.Code(now)
+	validity := key.Expires(now).Sub(now).Round(time.Second)
+	if *copyCode {
+		if err := clipboard.Copy(code); err != nil {
+			return err
+		}
+		fmt.Printf("TOTP code for %s copied to the clipboard (valid for %s).\n", c.Name, validity)
+		return nil
+	}
+	fmt.Println(code)
+	fmt.Fprintf(os.Stderr, "Valid for %s.\n", validity)
+	return nil
+}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"rdpctl/audit"
+	"rdpctl/model"
+	"rdpctl/trash"
+	"rdpctl/vault"
+)
+
+// runTrash implements `rdpctl trash <list|restore|empty>`.
+func runTrash(args []string, vaultPath string) error {
+	usage := fmt.Errorf("usage: rdpctl trash list | trash restore <name> | trash empty")
+	switch {
+	case len(args) == 1 && args[0] == "list":
+	case len(args) == 2 && args[0] == "restore":
+	case len(args) == 1 && args[0] == "empty":
+	default:
+		return usage
+	}
+
+	v, masterPassword, err := unlockVault(vaultPath)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+		return listTrash(v)
+	case "restore":
+		i, err := trash.Find(v, args[1])
+		if err != nil {
+			return err
+		}
+		before := append([]model.Connection(nil), v.Connections...)
+		c, err := trash.Restore(v, i)
+		if err != nil {
+			return err
+		}
+		if err := saveChanges(vaultPath, v, before, masterPassword); err != nil {
+			return err
+		}
+		fmt.Printf("Connection '%s' restored.\n", c.Name)
+		return nil
+	default:
+		removed := trash.Empty(v)
+		for i := range removed {
+			e := audit.NewEvent(audit.EventPurge).ForConnection(&removed[i])
+			e.Detail = "trash emptied"
+			if err := audit.Record(audit.PathFor(vaultPath), v, e); err != nil {
+				fmt.Printf("Warning: failed to write audit log: %v\n", err)
+				break
+			}
+		}
+		if err := vault.SaveVault(vaultPath, v, masterPassword); err != nil {
+			return err
+		}
+		fmt.Printf("%d connection(s) permanently deleted.\n", len(removed))
+		return nil
+	}
+}
+
+// listTrash prints the deleted connections in the trash, most recently deleted first.
+func listTrash(v *model.Vault) error {
+	if len(v.Trash) == 0 {
+		fmt.Println("The trash is empty.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tHOST\tGROUP\tDELETED\tPURGED")
+	for i := len(v.Trash) - 1; i >= 0; i-- {
+		item := v.Trash[i]
+		purged := "when emptied"
+		if expires := trash.Expires(item); !expires.IsZero() {
+			purged = expires.Local().Format("2006-01-02")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", item.Connection.Name, item.Connection.Host, item.Connection.Group,
+			item.DeletedAt.Local().Format("2006-01-02 15:04"), purged)
+	}
+	return w.Flush()
+}
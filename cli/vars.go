@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"rdpctl/vault"
+)
+
+// runVar implements `rdpctl var <list|set|unset>`, managing the vault variables referenced as ${var:name}.
+func runVar(args []string, vaultPath string) error {
+	usage := fmt.Errorf("usage: rdpctl var list | var set <name> <value> | var unset <name>")
+	if len(args) == 0 {
+		return usage
+	}
+
+	switch {
+	case args[0] == "list" && len(args) == 1:
+	case args[0] == "set" && len(args) == 3:
+	case args[0] == "unset" && len(args) == 2:
+	default:
+		return usage
+	}
+
+	v, masterPassword, err := unlockVault(vaultPath)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+		names := make([]string, 0, len(v.Variables))
+		for name := range v.Variables {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "NAME\tVALUE")
+		for _, name := range names {
+			fmt.Fprintf(w, "%s\t%s\n", name, v.Variables[name])
+		}
+		return w.Flush()
+	case "set":
+		if v.Variables == nil {
+			v.Variables = map[string]string{}
+		}
+		v.Variables[args[1]] = args[2]
+	case "unset":
+		if _, ok := v.Variables[args[1]]; !ok {
+			return fmt.Errorf("no variable named %q", args[1])
+		}
+		delete(v.Variables, args[1])
+	}
+
+	return vault.SaveVault(vaultPath, v, masterPassword)
+}
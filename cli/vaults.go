@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"rdpctl/config"
+	"rdpctl/model"
+	"rdpctl/transfer"
+	"rdpctl/ui"
+)
+
+// runVaults implements `rdpctl vaults`, listing the vault profiles in the configuration directory.
+func runVaults(args []string, vaultPath string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("usage: rdpctl vaults")
+	}
+
+	configDir, err := config.EnsureConfigDir()
+	if err != nil {
+		return err
+	}
+	profiles, err := config.Profiles(configDir)
+	if err != nil {
+		return err
+	}
+
+	listed := false
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "PROFILE\tPATH\tSTATUS")
+	for _, p := range profiles {
+		status := ""
+		if _, err := os.Stat(p.Path); os.IsNotExist(err) {
+			status = "not created"
+		}
+		if p.Path == vaultPath {
+			status = "selected"
+			listed = true
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", p.Name, p.Path, status)
+	}
+	if !listed {
+		name := config.ProfileName(configDir, vaultPath)
+		if name == vaultPath {
+			name = ""
+		}
+		fmt.Fprintf(w, "%s\t%s\tselected, not created\n", name, vaultPath)
+	}
+	return w.Flush()
+}
+
+// runCopy implements `rdpctl copy <name>... --to <vault>`, copying connections from the selected
+// vault into another existing vault.
+func runCopy(args []string, vaultPath string) error {
+	fs := flag.NewFlagSet("copy", flag.ContinueOnError)
+	to := fs.String("to", "", "destination vault profile name or vault file path")
+
+	names, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 || *to == "" {
+		return fmt.Errorf("usage: rdpctl copy <name>... --to <vault>")
+	}
+
+	configDir, err := config.EnsureConfigDir()
+	if err != nil {
+		return err
+	}
+	targetPath, err := config.ResolveVault(configDir, *to)
+	if err != nil {
+		return err
+	}
+	if targetPath == vaultPath {
+		return fmt.Errorf("source and destination are the same vault")
+	}
+
+	v, _, err := unlockVault(vaultPath)
+	if err != nil {
+		return err
+	}
+	conns := make([]*model.Connection, 0, len(names))
+	for _, name := range names {
+		c, err := findConnection(v, name)
+		if err != nil {
+			return err
+		}
+		conns = append(conns, c)
+	}
+
+	fmt.Printf("Unlocking destination vault '%s'.\n", ui.VaultLabel(targetPath))
+	target, targetPassword, err := unlockVault(targetPath)
+	if err != nil {
+		return err
+	}
+	before := append([]model.Connection(nil), target.Connections...)
+
+	copied := 0
+	for _, c := range conns {
+		if _, err := transfer.Copy(v, target, c); err != nil {
+			var dup *transfer.DuplicateError
+			if !errors.As(err, &dup) {
+				return err
+			}
+			fmt.Printf("Skipped: %v\n", err)
+			continue
+		}
+		fmt.Printf("Copied '%s'.\n", c.Name)
+		copied++
+	}
+	if copied == 0 {
+		return nil
+	}
+	return saveChanges(targetPath, target, before, targetPassword)
+}
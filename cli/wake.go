@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+
+	"rdpctl/ui"
+	"rdpctl/wol"
+)
+
+// runWake implements `rdpctl wake <name>`.
+func runWake(args []string, vaultPath string) error {
+	fs := flag.NewFlagSet("wake", flag.ContinueOnError)
+	wait := fs.Bool("wait", false, "wait for the RDP port to open after waking")
+	timeout := fs.Duration("timeout", wol.DefaultWaitTimeout, "how long to wait for the RDP port")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: rdpctl wake <name> [--wait] [--timeout 2m]")
+	}
+
+	v, _, err := unlockVault(vaultPath)
+	if err != nil {
+		return err
+	}
+
+	c, err := findConnection(v, positional[0])
+	if err != nil {
+		return err
+	}
+
+	if *wait {
+		return ui.WakeAndWait(c, *timeout)
+	}
+
+	if c.MACAddress == "" {
+		return fmt.Errorf("connection '%s' has no MAC address configured", c.Name)
+	}
+	if err := wol.Send(c.MACAddress, c.WakeBroadcast, c.WakePort); err != nil {
+		return fmt.Errorf("wake-on-LAN failed: %w", err)
+	}
+	fmt.Printf("Magic packet sent to %s (%s).\n", c.Name, c.MACAddress)
+	return nil
+}
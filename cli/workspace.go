@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"rdpctl/model"
+	"rdpctl/session"
+	"rdpctl/ui"
+	"rdpctl/vault"
+)
+
+// runWorkspace implements `rdpctl workspace <list|open>`.
+func runWorkspace(args []string, vaultPath string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: rdpctl workspace <list|open> [arguments]")
+	}
+
+	switch args[0] {
+	case "list":
+		return runWorkspaceList(args[1:], vaultPath)
+	case "open":
+		return runWorkspaceOpen(args[1:], vaultPath)
+	default:
+		return fmt.Errorf("unknown workspace command %q", args[0])
+	}
+}
+
+func runWorkspaceList(args []string, vaultPath string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("usage: rdpctl workspace list")
+	}
+
+	v, _, err := unlockVault(vaultPath)
+	if err != nil {
+		return err
+	}
+
+	if len(v.Workspaces) == 0 {
+		fmt.Println("No workspaces defined.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTAGGER\tCONNECTIONS")
+	for i := range v.Workspaces {
+		ws := &v.Workspaces[i]
+		names := make([]string, 0, len(ws.Members))
+		for _, m := range ws.Members {
+			names = append(names, connectionName(v, m.ConnectionID))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", ws.Name, ui.WorkspaceStagger(ws), strings.Join(names, ", "))
+	}
+	return w.Flush()
+}
+
+func runWorkspaceOpen(args []string, vaultPath string) error {
+	fs := flag.NewFlagSet("workspace open", flag.ContinueOnError)
+	stagger := fs.Duration("stagger", -1, "delay between launches (default: the workspace's setting)")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: rdpctl workspace open <name> [--stagger 2s]")
+	}
+
+	v, masterPassword, err := unlockVault(vaultPath)
+	if err != nil {
+		return err
+	}
+
+	ws, err := findWorkspace(v, positional[0])
+	if err != nil {
+		return err
+	}
+
+	delay := ui.WorkspaceStagger(ws)
+	if *stagger >= 0 {
+		delay = *stagger
+	}
+	openErr := ui.OpenWorkspace(v, ws, delay, func(c *model.Connection, sess *session.Session) {
+		ui.RecordLaunch(vaultPath, v, c, sess)
+	})
+	if err := vault.SaveVault(vaultPath, v, masterPassword); err != nil {
+		return err
+	}
+	return openErr
+}
+
+// findWorkspace returns the workspace whose name matches name (case-insensitive).
+func findWorkspace(v *model.Vault, name string) (*model.Workspace, error) {
+	for i := range v.Workspaces {
+		if strings.EqualFold(v.Workspaces[i].Name, name) {
+			return &v.Workspaces[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no workspace named %q", name)
+}
+
+// connectionName returns the name of the connection with the given ID, or the ID if it no longer exists.
+func connectionName(v *model.Vault, id string) string {
+	for _, c := range v.Connections {
+		if c.ID == id {
+			return c.Name
+		}
+	}
+	return id
+}
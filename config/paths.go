@@ -1,13 +1,56 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"syscall"
 )
 
-// ConfigDir returns the path to the rdpctl configuration directory (~/.config/rdp/).
+// Environment variables that select rdpctl's files.
+const (
+	EnvConfigDir = "RDPCTL_CONFIG_DIR" // Configuration directory, e.g. on a USB stick
+	EnvVault     = "RDPCTL_VAULT"      // Vault profile name or vault file path, like --vault
+	EnvKeyfile   = "RDPCTL_KEYFILE"    // Keyfile of a vault that requires one, like --keyfile
+)
+
+// DefaultProfile is the name of the vault stored directly in the configuration directory.
+const DefaultProfile = "default"
+
+// legacyFallback is the directory of earlier versions when it could not be moved into place, in
+// which case it stays the configuration directory for this run.
+var legacyFallback string
+
+// profileNamePattern restricts profile names so they are safe as file names.
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ConfigDir returns the path to the rdpctl configuration directory: $RDPCTL_CONFIG_DIR if set,
+// otherwise rdpctl under $XDG_CONFIG_HOME (~/.config/rdpctl/ when that is unset).
 func ConfigDir() (string, error) {
+	if legacyFallback != "" {
+		return legacyFallback, nil
+	}
+	if dir := os.Getenv(EnvConfigDir); dir != "" {
+		return filepath.Abs(dir)
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "rdpctl"), nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".config", "rdpctl"), nil
+}
+
+// legacyConfigDir returns the configuration directory used by earlier versions (~/.config/rdp/).
+func legacyConfigDir() (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return "", fmt.Errorf("failed to get user home directory: %w", err)
@@ -15,13 +58,25 @@ func ConfigDir() (string, error) {
 	return filepath.Join(homeDir, ".config", "rdp"), nil
 }
 
-// EnsureConfigDir ensures the rdpctl configuration directory exists.
+// EnsureConfigDir ensures the rdpctl configuration directory exists. The first time it runs
+// after an upgrade it moves the directory of earlier versions (~/.config/rdp/) into place; if
+// that fails, it warns and keeps using the old directory.
 func EnsureConfigDir() (string, error) {
 	configDir, err := ConfigDir()
 	if err != nil {
 		return "", err
 	}
 
+	if os.Getenv(EnvConfigDir) == "" && legacyFallback == "" {
+		if err := migrateLegacyConfigDir(configDir); err != nil {
+			legacyFallback, _ = legacyConfigDir()
+			fmt.Fprintf(os.Stderr, "Warning: %v; using %s for now.\n", err, legacyFallback)
+		}
+	}
+	if legacyFallback != "" {
+		return legacyFallback, nil
+	}
+
 	if err := os.MkdirAll(configDir, 0700); err != nil {
 		return "", fmt.Errorf("failed to create config directory %s: %w", configDir, err)
 	}
@@ -29,7 +84,153 @@ func EnsureConfigDir() (string, error) {
 	return configDir, nil
 }
 
-// VaultPath returns the full path to the encrypted vault file.
+// migrateLegacyConfigDir moves ~/.config/rdp/ to configDir if only the former exists. Across
+// filesystems it copies the directory and then removes the original.
+func migrateLegacyConfigDir(configDir string) error {
+	legacyDir, err := legacyConfigDir()
+	if err != nil {
+		return err
+	}
+	if legacyDir == configDir {
+		return nil
+	}
+	if _, err := os.Stat(configDir); err == nil {
+		return nil
+	}
+	if _, err := os.Stat(VaultPath(legacyDir)); err != nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(configDir), 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(configDir), err)
+	}
+	err = os.Rename(legacyDir, configDir)
+	if errors.Is(err, syscall.EXDEV) {
+		if err = copyDir(legacyDir, configDir); err != nil {
+			os.RemoveAll(configDir) // Did not exist before
+		} else if removeErr := os.RemoveAll(legacyDir); removeErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to remove %s after copying it: %v\n", legacyDir, removeErr)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to move config directory %s to %s: %w", legacyDir, configDir, err)
+	}
+	fmt.Fprintf(os.Stderr, "Moved configuration directory %s to %s.\n", legacyDir, configDir)
+	return nil
+}
+
+// copyDir copies the directory tree at src to dst, which must not exist, keeping permissions and
+// symbolic links.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.Mkdir(target, info.Mode().Perm())
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(target, data, info.Mode().Perm())
+		default:
+			return nil // Sockets, devices and other special files are skipped
+		}
+	})
+}
+
+// VaultPath returns the full path to the default encrypted vault file.
 func VaultPath(dirname string) string {
 	return filepath.Join(dirname, "vault.enc")
 }
+
+// ProfileDir returns the directory holding the vaults of named profiles.
+func ProfileDir(dirname string) string {
+	return filepath.Join(dirname, "vaults")
+}
+
+// ProfilePath returns the vault file of the named profile. The default profile is the vault
+// in the configuration directory itself.
+func ProfilePath(dirname, name string) (string, error) {
+	if name == DefaultProfile {
+		return VaultPath(dirname), nil
+	}
+	if !profileNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid vault profile name %q: use letters, digits, '.', '_' and '-'", name)
+	}
+	return filepath.Join(ProfileDir(dirname), name+".enc"), nil
+}
+
+// ResolveVault turns a --vault or RDPCTL_VAULT selector into a vault file path. An empty selector
+// means the default profile; a selector containing a path separator or ending in ".enc" is a file
+// path; anything else names a profile.
+func ResolveVault(dirname, selector string) (string, error) {
+	switch {
+	case selector == "":
+		return VaultPath(dirname), nil
+	case strings.ContainsRune(selector, os.PathSeparator) || strings.HasSuffix(selector, ".enc"):
+		return filepath.Abs(selector)
+	default:
+		return ProfilePath(dirname, selector)
+	}
+}
+
+// Profile is a named vault in the configuration directory.
+type Profile struct {
+	Name string
+	Path string
+}
+
+// Profiles lists the default profile followed by the named profiles in alphabetical order.
+func Profiles(dirname string) ([]Profile, error) {
+	profiles := []Profile{{Name: DefaultProfile, Path: VaultPath(dirname)}}
+
+	matches, err := filepath.Glob(filepath.Join(ProfileDir(dirname), "*.enc"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list vault profiles: %w", err)
+	}
+	sort.Strings(matches)
+	for _, path := range matches {
+		profiles = append(profiles, Profile{Name: strings.TrimSuffix(filepath.Base(path), ".enc"), Path: path})
+	}
+	return profiles, nil
+}
+
+// ProfileName returns the profile name of the vault at path, or path itself if it is not a profile.
+func ProfileName(dirname, path string) string {
+	if path == VaultPath(dirname) {
+		return DefaultProfile
+	}
+	if filepath.Dir(path) == ProfileDir(dirname) && strings.HasSuffix(path, ".enc") {
+		return strings.TrimSuffix(filepath.Base(path), ".enc")
+	}
+	return path
+}
+
+// SessionsPath returns the full path to the runtime state file tracking background sessions.
+func SessionsPath(dirname string) string {
+	return filepath.Join(dirname, "sessions.json")
+}
+
+// LogDir returns the directory holding logs of background RDP sessions.
+func LogDir(dirname string) string {
+	return filepath.Join(dirname, "logs")
+}
@@ -0,0 +1,306 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Settings holds the global rdpctl configuration read from config.yaml.
+// Every field has a default, so the file only needs to contain the values that differ.
+type Settings struct {
+	Launcher        LauncherSettings  `yaml:"launcher"`
+	IdleLockMinutes int               `yaml:"idleLockMinutes"` // Ask for the master password again after this long idle in the menu; 0 disables
+	BackupCount     int               `yaml:"backupCount"`     // Previous vault versions kept as vault.enc.1, .2, ...
+	HistoryLimit    int               `yaml:"historyLimit"`    // Prior versions of each connection kept in the vault; 0 disables
+	TrashDays       int               `yaml:"trashDays"`       // Days deleted connections stay in the trash; 0 keeps them until emptied
+	OutputFormat    string            `yaml:"outputFormat"`    // Default output of listing commands: table or json
+	Reconnect       ReconnectSettings `yaml:"reconnect"`
+	Wake            WakeSettings      `yaml:"wake"`
+	Menu            MenuSettings      `yaml:"menu"`
+	Workspace       WorkspaceSettings `yaml:"workspace"`
+	Unlock          UnlockSettings    `yaml:"unlock"`
+	Generator       GeneratorSettings `yaml:"generator"`
+
+	PasswordMaxAgeDays int    `yaml:"passwordMaxAgeDays"` // Warn about stored passwords older than this; 0 disables
+	BreachList         string `yaml:"breachList"`         // Offline Pwned Passwords file or range directory checked by audit-security
+}
+
+// LauncherSettings selects the RDP client and the arguments every connection starts with.
+type LauncherSettings struct {
+	Binary   string   `yaml:"binary"`   // Client executable name, looked up in PATH
+	Path     string   `yaml:"path"`     // Full path to the client; overrides Binary when set
+	BaseArgs []string `yaml:"baseArgs"` // Arguments placed before the connection's own
+}
+
+// ReconnectSettings is the global policy for relaunching the client after network failures.
+type ReconnectSettings struct {
+	MaxAttempts  int      `yaml:"maxAttempts"`
+	InitialDelay Duration `yaml:"initialDelay"`
+	MaxDelay     Duration `yaml:"maxDelay"`
+	StableAfter  Duration `yaml:"stableAfter"`
+}
+
+// WakeSettings are the Wake-on-LAN defaults.
+type WakeSettings struct {
+	Timeout   Duration `yaml:"timeout"`
+	Broadcast string   `yaml:"broadcast"`
+	Port      int      `yaml:"port"`
+}
+
+// MenuSettings control the interactive menu.
+type MenuSettings struct {
+	RecentCount int `yaml:"recentCount"` // Recently used hosts shown at the top of the main menu
+	PageSize    int `yaml:"pageSize"`    // Rows shown at once in selection lists
+}
+
+// WorkspaceSettings are the workspace defaults.
+type WorkspaceSettings struct {
+	Stagger Duration `yaml:"stagger"` // Delay between launches when a workspace sets none
+}
+
+// UnlockSettings control unlocking the vault.
+type UnlockSettings struct {
+	MaxAttempts     int    `yaml:"maxAttempts"`
+	PasswordCommand string `yaml:"passwordCommand"` // Shell command printing the master password, used instead of the prompt
+}
+
+// GeneratorSettings are the defaults of the password generator.
+type GeneratorSettings struct {
+	Length    int    `yaml:"length"`
+	Lower     bool   `yaml:"lower"`
+	Upper     bool   `yaml:"upper"`
+	Digits    bool   `yaml:"digits"`
+	Symbols   bool   `yaml:"symbols"`
+	Words     int    `yaml:"words"` // Generate a passphrase of this many words instead; 0 generates characters
+	Separator string `yaml:"separator"`
+}
+
+// Duration is a time.Duration written as a string such as "90s" or "2m" in the config file.
+type Duration time.Duration
+
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return time.Duration(d).String(), nil
+}
+
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	parsed, err := time.ParseDuration(node.Value)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", node.Value, err)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// DefaultSettings returns the settings used when config.yaml does not override them.
+func DefaultSettings() Settings {
+	return Settings{
+		Launcher: LauncherSettings{
+			Binary:   "xfreerdp",
+			BaseArgs: []string{"+clipboard", "+dynamic-resolution"},
+		},
+		HistoryLimit: 20,
+		TrashDays:    30,
+		OutputFormat: "table",
+		Reconnect: ReconnectSettings{
+			MaxAttempts:  5,
+			InitialDelay: Duration(2 * time.Second),
+			MaxDelay:     Duration(time.Minute),
+			StableAfter:  Duration(time.Minute),
+		},
+		Wake: WakeSettings{
+			Timeout:   Duration(2 * time.Minute),
+			Broadcast: "255.255.255.255",
+			Port:      9,
+		},
+		Menu: MenuSettings{
+			RecentCount: 5,
+			PageSize:    10,
+		},
+		Workspace: WorkspaceSettings{
+			Stagger: Duration(2 * time.Second),
+		},
+		Unlock: UnlockSettings{
+			MaxAttempts: 3,
+		},
+		Generator: GeneratorSettings{
+			Length:    24,
+			Lower:     true,
+			Upper:     true,
+			Digits:    true,
+			Symbols:   true,
+			Separator: "-",
+		},
+	}
+}
+
+// SettingsPath returns the full path to the configuration file.
+func SettingsPath(dirname string) string {
+	return filepath.Join(dirname, "config.yaml")
+}
+
+// LoadSettings reads the configuration file on top of the defaults. A missing file is not an error.
+func LoadSettings(path string) (Settings, error) {
+	s := DefaultSettings()
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return s, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if err := s.Validate(); err != nil {
+		return s, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// SaveSettings writes the configuration file.
+func SaveSettings(path string, s Settings) error {
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// Validate checks that the settings are usable.
+func (s Settings) Validate() error {
+	if s.Launcher.Binary == "" && s.Launcher.Path == "" {
+		return fmt.Errorf("launcher.binary or launcher.path must be set")
+	}
+	if s.OutputFormat != "table" && s.OutputFormat != "json" {
+		return fmt.Errorf("outputFormat must be table or json, not %q", s.OutputFormat)
+	}
+	if s.IdleLockMinutes < 0 || s.BackupCount < 0 || s.HistoryLimit < 0 || s.TrashDays < 0 || s.Reconnect.MaxAttempts < 0 {
+		return fmt.Errorf("idleLockMinutes, backupCount, historyLimit, trashDays and reconnect.maxAttempts must not be negative")
+	}
+	if s.Menu.RecentCount < 0 || s.Menu.PageSize < 1 || s.Unlock.MaxAttempts < 1 {
+		return fmt.Errorf("menu.recentCount must not be negative, and menu.pageSize and unlock.maxAttempts must be at least 1")
+	}
+	if s.Wake.Port < 1 || s.Wake.Port > 65535 {
+		return fmt.Errorf("wake.port must be between 1 and 65535")
+	}
+	if s.PasswordMaxAgeDays < 0 || s.Generator.Words < 0 {
+		return fmt.Errorf("passwordMaxAgeDays and generator.words must not be negative")
+	}
+	g := s.Generator
+	if g.Length < 8 || !(g.Lower || g.Upper || g.Digits || g.Symbols) {
+		return fmt.Errorf("generator.length must be at least 8, with at least one character class enabled")
+	}
+	return nil
+}
+
+// Keys returns the dotted names of every setting, e.g. "reconnect.maxAttempts", in sorted order.
+func (s *Settings) Keys() []string {
+	var keys []string
+	walkSettings(reflect.ValueOf(s).Elem(), "", func(key string, _ reflect.Value) {
+		keys = append(keys, key)
+	})
+	sort.Strings(keys)
+	return keys
+}
+
+// Get returns the value of the setting with the given dotted name, formatted as Set accepts it.
+func (s *Settings) Get(key string) (string, error) {
+	field, err := s.lookup(key)
+	if err != nil {
+		return "", err
+	}
+
+	switch v := field.Interface().(type) {
+	case Duration:
+		return time.Duration(v).String(), nil
+	case []string:
+		return strings.Join(v, ","), nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// Set parses value into the setting with the given dotted name. Lists are comma-separated
+// and durations use Go syntax such as "90s".
+func (s *Settings) Set(key, value string) error {
+	field, err := s.lookup(key)
+	if err != nil {
+		return err
+	}
+
+	switch field.Interface().(type) {
+	case Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%s: invalid duration %q", key, value)
+		}
+		field.Set(reflect.ValueOf(Duration(d)))
+	case []string:
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		field.Set(reflect.ValueOf(list))
+	case bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s: invalid boolean %q", key, value)
+		}
+		field.SetBool(b)
+	case int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s: invalid number %q", key, value)
+		}
+		field.SetInt(int64(n))
+	case string:
+		field.SetString(value)
+	default:
+		return fmt.Errorf("%s: unsupported setting type", key)
+	}
+
+	return s.Validate()
+}
+
+func (s *Settings) lookup(key string) (reflect.Value, error) {
+	var found reflect.Value
+	walkSettings(reflect.ValueOf(s).Elem(), "", func(k string, v reflect.Value) {
+		if strings.EqualFold(k, key) {
+			found = v
+		}
+	})
+	if !found.IsValid() {
+		return found, fmt.Errorf("unknown setting %q (see rdpctl config list)", key)
+	}
+	return found, nil
+}
+
+// walkSettings calls fn for every leaf setting, naming it by its dotted YAML path.
+func walkSettings(v reflect.Value, prefix string, fn func(key string, field reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key := prefix + strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			walkSettings(field, key+".", fn)
+			continue
+		}
+		fn(key, field)
+	}
+}
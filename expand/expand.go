@@ -0,0 +1,227 @@
+package expand
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"rdpctl/model"
+)
+
+// Context supplies the values references can resolve to.
+type Context struct {
+	Vars      map[string]string               // Vault variables, referenced as ${var:name}
+	Conn      *model.Connection               // Connection fields, referenced as ${conn:field}
+	LookupEnv func(key string) (string, bool) // Environment lookup; os.LookupEnv when nil
+}
+
+// UndefinedError reports a reference that has no value.
+type UndefinedError struct {
+	Ref string
+}
+
+func (e *UndefinedError) Error() string {
+	return fmt.Sprintf("undefined reference ${%s}", e.Ref)
+}
+
+// connFields are the connection fields that may be referenced. Secrets are deliberately absent.
+var connFields = map[string]func(c *model.Connection) string{
+	"name":     func(c *model.Connection) string { return c.Name },
+	"host":     func(c *model.Connection) string { return c.Host },
+	"group":    func(c *model.Connection) string { return c.Group },
+	"domain":   func(c *model.Connection) string { return c.Domain },
+	"username": func(c *model.Connection) string { return c.Username },
+	"gateway":  func(c *model.Connection) string { return c.Gateway },
+}
+
+// String expands references in s:
+//
+//	${NAME} or ${env:NAME}   environment variable
+//	${var:name}              vault variable
+//	${conn:field}            connection field (name, host, group, domain, username, gateway)
+//	$${                      a literal "${"
+//
+// Expansion is a single pass: substituted values are never expanded again. A "$" that does not
+// start a reference is kept as is, so arguments such as /share:C$ need no escaping.
+func String(s string, ctx Context) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if strings.HasPrefix(s[i:], "$${") {
+			b.WriteString("${")
+			i += 3
+			continue
+		}
+		if !strings.HasPrefix(s[i:], "${") {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+
+		end := strings.IndexByte(s[i+2:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated reference in %q", s)
+		}
+		ref := s[i+2 : i+2+end]
+
+		value, err := resolve(ref, ctx)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(value)
+		i += 2 + end + 1
+	}
+	return b.String(), nil
+}
+
+// Strings expands every element of values.
+func Strings(values []string, ctx Context) ([]string, error) {
+	if values == nil {
+		return nil, nil
+	}
+	expanded := make([]string, len(values))
+	for i, v := range values {
+		var err error
+		if expanded[i], err = String(v, ctx); err != nil {
+			return nil, err
+		}
+	}
+	return expanded, nil
+}
+
+// Connection returns a copy of c with references expanded in its host, domain, username, gateway
+// and extra arguments. The password and other secrets are never expanded.
+func Connection(c *model.Connection, vars map[string]string) (model.Connection, error) {
+	ctx := Context{Vars: vars, Conn: c}
+	expanded := *c
+
+	fields := []struct {
+		name string
+		ptr  *string
+	}{
+		{"host", &expanded.Host},
+		{"domain", &expanded.Domain},
+		{"username", &expanded.Username},
+		{"gateway", &expanded.Gateway},
+	}
+	for _, f := range fields {
+		value, err := String(*f.ptr, ctx)
+		if err != nil {
+			return expanded, fmt.Errorf("%s: %w", f.name, err)
+		}
+		*f.ptr = value
+	}
+
+	args, err := Strings(c.ExtraArgs, ctx)
+	if err != nil {
+		return expanded, fmt.Errorf("extra arguments: %w", err)
+	}
+	expanded.ExtraArgs = args
+
+	return expanded, nil
+}
+
+func resolve(ref string, ctx Context) (string, error) {
+	kind, name, scoped := strings.Cut(ref, ":")
+	if !scoped {
+		kind, name = "env", ref
+	}
+	if name == "" {
+		return "", fmt.Errorf("empty reference ${%s}", ref)
+	}
+
+	switch kind {
+	case "env":
+		lookup := ctx.LookupEnv
+		if lookup == nil {
+			lookup = os.LookupEnv
+		}
+		if value, ok := lookup(name); ok {
+			return value, nil
+		}
+	case "var":
+		if value, ok := ctx.Vars[name]; ok {
+			return value, nil
+		}
+	case "conn":
+		get, ok := connFields[strings.ToLower(name)]
+		if !ok {
+			return "", fmt.Errorf("${%s}: unknown or secret connection field %q", ref, name)
+		}
+		if ctx.Conn != nil {
+			return get(ctx.Conn), nil
+		}
+	default:
+		return "", fmt.Errorf("${%s}: unknown reference type %q (expected env, var or conn)", ref, kind)
+	}
+
+	return "", &UndefinedError{Ref: ref}
+}
+
+// VarRefs returns the names of the vault variables referenced as ${var:name} in s, in order of
+// first appearance.
+func VarRefs(s string) []string {
+	var names []string
+	seen := map[string]bool{}
+	rewrite(s, func(ref string) (string, bool) {
+		if kind, name, _ := strings.Cut(ref, ":"); kind == "var" && name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+		return "", false
+	})
+	return names
+}
+
+// ReplaceVars returns s with each ${var:name} reference to a variable in values replaced by its
+// value. A "${" in a value is escaped as "$${", so the result expands to the same string as s
+// expanded with values. Other references are kept as they are.
+func ReplaceVars(s string, values map[string]string) string {
+	return rewrite(s, func(ref string) (string, bool) {
+		kind, name, _ := strings.Cut(ref, ":")
+		value, ok := values[name]
+		if kind != "var" || !ok {
+			return "", false
+		}
+		return strings.ReplaceAll(value, "${", "$${"), true
+	})
+}
+
+// rewrite copies s, passing each reference to replace and substituting its result when ok is true.
+// Escaped "$${" sequences and an unterminated reference are copied unchanged.
+func rewrite(s string, replace func(ref string) (string, bool)) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if strings.HasPrefix(s[i:], "$${") {
+			b.WriteString("$${")
+			i += 3
+			continue
+		}
+		if !strings.HasPrefix(s[i:], "${") {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+
+		end := strings.IndexByte(s[i+2:], '}')
+		if end < 0 {
+			b.WriteString(s[i:])
+			break
+		}
+		ref := s[i+2 : i+2+end]
+		if value, ok := replace(ref); ok {
+			b.WriteString(value)
+		} else {
+			b.WriteString(s[i : i+2+end+1])
+		}
+		i += 2 + end + 1
+	}
+	return b.String()
+}
@@ -0,0 +1,212 @@
+package fuzzy
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"rdpctl/model"
+	"rdpctl/usage"
+)
+
+// Match scores. A matched character is worth scoreMatch, with bonuses for runs of consecutive
+// characters and for characters that start a word, and penalties for the gaps in between.
+const (
+	scoreMatch        = 16
+	bonusConsecutive  = 8
+	bonusBoundary     = 10
+	penaltyGapStart   = 3
+	penaltyGapExtend  = 1
+	maxGapPenalty     = 12
+	bonusExact        = 200 // Query equals a connection's name or host
+	bonusPrefix       = 100 // Name or host starts with the query
+	maxRecencyBonus   = 40
+	recencyMultiplier = 10 // Bonus per decayed connect (see usage.Frequency)
+)
+
+// ClearMargin is how far the best result must score above the next one to count as the unique
+// best match of a query.
+const ClearMargin = bonusPrefix / 2
+
+// field is a searchable connection field. Matches in heavier fields count for more.
+type field struct {
+	weight int
+	value  func(c *model.Connection) string
+}
+
+var fields = []field{
+	{3, func(c *model.Connection) string { return c.Name }},
+	{3, func(c *model.Connection) string { return c.Host }},
+	{2, func(c *model.Connection) string { return c.Username }},
+	{2, func(c *model.Connection) string { return c.Domain }},
+	{2, func(c *model.Connection) string { return c.Group }},
+	{2, func(c *model.Connection) string { return strings.Join(c.Tags, " ") }},
+	{1, func(c *model.Connection) string { return c.Notes }},
+}
+
+// Result is a connection matched by a query.
+type Result struct {
+	Connection    *model.Connection
+	Score         int
+	Exact         bool  // The query equals the connection's name or host
+	NamePositions []int // Rune indexes of matched characters in the name, for highlighting
+	HostPositions []int // Rune indexes of matched characters in the host
+}
+
+// Match reports whether the characters of pattern occur in text in order, ignoring case, and
+// scores the best such match. positions are the rune indexes of the matched characters in text.
+func Match(pattern, text string) (score int, positions []int, ok bool) {
+	p := []rune(strings.ToLower(pattern))
+	t := []rune(strings.ToLower(text))
+	if len(p) == 0 || len(p) > len(t) {
+		return 0, nil, false
+	}
+	orig := []rune(text)
+
+	best := math.MinInt
+	for start := range t {
+		if t[start] != p[0] {
+			continue
+		}
+		candidate, s := matchFrom(p, t, orig, start)
+		if candidate != nil && s > best {
+			best, positions = s, candidate
+		}
+	}
+	if positions == nil {
+		return 0, nil, false
+	}
+	return best, positions, true
+}
+
+// matchFrom matches p against t starting at the rune index start, taking each following pattern
+// character at its next occurrence, and returns the positions and their score.
+func matchFrom(p, t, orig []rune, start int) ([]int, int) {
+	positions := make([]int, 0, len(p))
+	score := 0
+	j := start
+	for i, r := range p {
+		for j < len(t) && t[j] != r {
+			j++
+		}
+		if j == len(t) {
+			return nil, 0
+		}
+
+		score += scoreMatch
+		if isBoundary(orig, j) {
+			score += bonusBoundary
+		}
+		if i > 0 {
+			if gap := j - positions[i-1] - 1; gap == 0 {
+				score += bonusConsecutive
+			} else {
+				score -= min(penaltyGapStart+penaltyGapExtend*(gap-1), maxGapPenalty)
+			}
+		}
+		positions = append(positions, j)
+		j++
+	}
+	return positions, score
+}
+
+// isBoundary reports whether the rune at index i starts a word: it is the first rune, follows a
+// separator, or is an upper-case letter after a lower-case one.
+func isBoundary(text []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev, cur := text[i-1], text[i]
+	if !unicode.IsLetter(prev) && !unicode.IsDigit(prev) {
+		return true
+	}
+	return unicode.IsLower(prev) && unicode.IsUpper(cur)
+}
+
+// Rank returns the connections matching every word of query, best first. A word matches when it
+// fuzzily matches the name, host, username, domain, group, tags or notes. Exact and prefix hits
+// on the name or host rank first, and recently used hosts rank above others. Connections that score
+// the same keep their order in conns. An empty query matches every connection.
+func Rank(conns []*model.Connection, query string, now time.Time) []Result {
+	words := strings.Fields(query)
+	results := make([]Result, 0, len(conns))
+	for _, c := range conns {
+		r, ok := score(c, words, strings.TrimSpace(query))
+		if !ok {
+			continue
+		}
+		r.Score += min(int(usage.Frequency(c, now)*recencyMultiplier), maxRecencyBonus)
+		results = append(results, r)
+	}
+
+	if len(words) > 0 {
+		sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	}
+	return results
+}
+
+// Best returns the unique best match among ranked results: the only result, the only exact one, or
+// a result that scores at least ClearMargin above the next. ok is false when the query is ambiguous.
+func Best(results []Result) (best Result, ok bool) {
+	switch len(results) {
+	case 0:
+		return Result{}, false
+	case 1:
+		return results[0], true
+	}
+
+	var exact []Result
+	for _, r := range results {
+		if r.Exact {
+			exact = append(exact, r)
+		}
+	}
+	if len(exact) == 1 {
+		return exact[0], true
+	}
+	if len(exact) == 0 && results[0].Score-results[1].Score >= ClearMargin {
+		return results[0], true
+	}
+	return Result{}, false
+}
+
+// score matches every word against the fields of c.
+func score(c *model.Connection, words []string, query string) (Result, bool) {
+	r := Result{Connection: c}
+	if len(words) == 0 {
+		return r, true
+	}
+
+	for _, w := range words {
+		best := 0
+		for _, f := range fields {
+			if s, _, ok := Match(w, f.value(c)); ok && s*f.weight > best {
+				best = s * f.weight
+			}
+		}
+		if best == 0 {
+			return Result{}, false
+		}
+		r.Score += best
+
+		if _, positions, ok := Match(w, c.Name); ok {
+			r.NamePositions = append(r.NamePositions, positions...)
+		}
+		if _, positions, ok := Match(w, c.Host); ok {
+			r.HostPositions = append(r.HostPositions, positions...)
+		}
+	}
+
+	q := strings.ToLower(query)
+	name, host := strings.ToLower(c.Name), strings.ToLower(c.Host)
+	switch {
+	case name == q || host == q:
+		r.Exact = true
+		r.Score += bonusExact
+	case strings.HasPrefix(name, q) || strings.HasPrefix(host, q):
+		r.Score += bonusPrefix
+	}
+	return r, true
+}
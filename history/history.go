@@ -0,0 +1,153 @@
+package history
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"rdpctl/audit"
+	"rdpctl/model"
+)
+
+// Limit is how many prior versions of each connection are kept in the vault. 0 disables the history.
+var Limit = 20
+
+// Record compares the connections before an operation with the vault's current connections and
+// adds the prior version of every edited connection to its history, dropping the oldest versions
+// beyond Limit. The history of connections no longer in the vault or its trash is removed.
+func Record(v *model.Vault, before []model.Connection, now time.Time) {
+	old := make(map[string]*model.Connection, len(before))
+	for i := range before {
+		old[before[i].ID] = &before[i]
+	}
+
+	for i := range v.Connections {
+		c := &v.Connections[i]
+		prev, ok := old[c.ID]
+		if !ok || Limit <= 0 {
+			continue
+		}
+		changes := audit.Diff(prev, c)
+		if len(changes) == 0 {
+			continue
+		}
+
+		changed := make([]string, len(changes))
+		for j, change := range changes {
+			changed[j] = change.Field
+		}
+		h := historyFor(v, c.ID)
+		h.Versions = append(h.Versions, model.ConnectionVersion{
+			Version:    Current(v, c.ID),
+			ReplacedAt: now,
+			Changed:    changed,
+			Connection: *prev,
+		})
+	}
+
+	prune(v)
+}
+
+// Versions returns the kept prior versions of the connection with the given ID, oldest first.
+func Versions(v *model.Vault, id string) []model.ConnectionVersion {
+	for _, h := range v.History {
+		if h.ConnectionID == id {
+			return h.Versions
+		}
+	}
+	return nil
+}
+
+// Current returns the version number of the connection's current state: 1 for a connection that
+// was never edited, and one more than its newest prior version otherwise.
+func Current(v *model.Vault, id string) int {
+	versions := Versions(v, id)
+	if len(versions) == 0 {
+		return 1
+	}
+	return versions[len(versions)-1].Version + 1
+}
+
+// Restore replaces c with its prior version n. The connection keeps its ID, creation time and usage
+// statistics, so only its settings are rolled back. The caller records the restore, which keeps
+// the replaced settings as a new version, and saves the vault.
+func Restore(v *model.Vault, c *model.Connection, n int, now time.Time) error {
+	var restored *model.Connection
+	for _, version := range Versions(v, c.ID) {
+		if version.Version == n {
+			restored = &version.Connection
+			break
+		}
+	}
+	if restored == nil {
+		return fmt.Errorf("%s has no version %d (current version: %d)", c.Name, n, Current(v, c.ID))
+	}
+
+	for i := range v.Connections {
+		other := &v.Connections[i]
+		if other.ID != c.ID && strings.EqualFold(other.Name, restored.Name) {
+			return fmt.Errorf("version %d is named %q, which another connection now uses", n, restored.Name)
+		}
+	}
+
+	r := *restored
+	r.ID = c.ID
+	r.CreatedAt = c.CreatedAt
+	r.LastConnectedAt = c.LastConnectedAt
+	r.ConnectCount = c.ConnectCount
+	r.FrequencyScore = c.FrequencyScore
+	r.UpdatedAt = now
+	*c = r
+	return nil
+}
+
+// Remove drops the history of the connection with the given ID.
+func Remove(v *model.Vault, id string) {
+	for i := range v.History {
+		if v.History[i].ConnectionID == id {
+			v.History = append(v.History[:i], v.History[i+1:]...)
+			break
+		}
+	}
+	if len(v.History) == 0 {
+		v.History = nil
+	}
+}
+
+// historyFor returns the history of the connection with the given ID, adding an empty one if needed.
+func historyFor(v *model.Vault, id string) *model.ConnectionHistory {
+	for i := range v.History {
+		if v.History[i].ConnectionID == id {
+			return &v.History[i]
+		}
+	}
+	v.History = append(v.History, model.ConnectionHistory{ConnectionID: id})
+	return &v.History[len(v.History)-1]
+}
+
+// prune trims every history to Limit versions and drops the histories of removed connections.
+// Trashed connections keep theirs until they are purged.
+func prune(v *model.Vault) {
+	present := make(map[string]bool, len(v.Connections)+len(v.Trash))
+	for _, c := range v.Connections {
+		present[c.ID] = true
+	}
+	for _, item := range v.Trash {
+		present[item.Connection.ID] = true
+	}
+
+	kept := v.History[:0]
+	for _, h := range v.History {
+		if !present[h.ConnectionID] || Limit <= 0 {
+			continue
+		}
+		if len(h.Versions) > Limit {
+			h.Versions = h.Versions[len(h.Versions)-Limit:]
+		}
+		kept = append(kept, h)
+	}
+	v.History = kept
+	if len(v.History) == 0 {
+		v.History = nil
+	}
+}
@@ -0,0 +1,215 @@
+package importer
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+
+	"rdpctl/model"
+)
+
+// ErrWrongPassword is returned when the password of a password-protected export is wrong.
+var ErrWrongPassword = errors.New("wrong password for the export file")
+
+// Key derivation functions of password-protected Bitwarden exports.
+const (
+	bitwardenPBKDF2   = 0
+	bitwardenArgon2id = 1
+)
+
+type bitwardenExport struct {
+	Encrypted         bool   `json:"encrypted"`
+	PasswordProtected bool   `json:"passwordProtected"`
+	Salt              string `json:"salt"`
+	KdfType           int    `json:"kdfType"`
+	KdfIterations     int    `json:"kdfIterations"`
+	KdfMemory         int    `json:"kdfMemory"` // MiB
+	KdfParallelism    int    `json:"kdfParallelism"`
+	KeyValidation     string `json:"encKeyValidation_DO_NOT_EDIT"`
+	Data              string `json:"data"`
+
+	Folders     []bitwardenFolder `json:"folders"`
+	Collections []bitwardenFolder `json:"collections"`
+	Items       []bitwardenItem   `json:"items"`
+}
+
+type bitwardenFolder struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type bitwardenItem struct {
+	Name          string   `json:"name"`
+	Notes         string   `json:"notes"`
+	FolderID      string   `json:"folderId"`
+	CollectionIDs []string `json:"collectionIds"`
+	Login         *struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		TOTP     string `json:"totp"`
+		URIs     []struct {
+			URI string `json:"uri"`
+		} `json:"uris"`
+	} `json:"login"`
+	Fields []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"fields"`
+}
+
+// ReadBitwarden reads a Bitwarden JSON export. Folders, or the first collection of items in an
+// organization export, become connection groups; Bitwarden already nests folders with "/". For a
+// password-protected export, password is called to ask for the file's password.
+func ReadBitwarden(data []byte, password func() (string, error)) ([]model.Connection, []Skipped, error) {
+	var export bitwardenExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, nil, fmt.Errorf("not a Bitwarden JSON export: %w", err)
+	}
+
+	if export.Encrypted {
+		if !export.PasswordProtected {
+			return nil, nil, fmt.Errorf("this Bitwarden export is encrypted with the account key; export it again as JSON, or as encrypted JSON protected by a password")
+		}
+		pw, err := password()
+		if err != nil {
+			return nil, nil, err
+		}
+		plain, err := decryptBitwarden(&export, pw)
+		if err != nil {
+			return nil, nil, err
+		}
+		export = bitwardenExport{}
+		if err := json.Unmarshal(plain, &export); err != nil {
+			return nil, nil, fmt.Errorf("failed to read decrypted export: %w", err)
+		}
+	}
+
+	groups := map[string]string{}
+	for _, f := range export.Collections {
+		groups[f.ID] = f.Name
+	}
+	for _, f := range export.Folders {
+		groups[f.ID] = f.Name
+	}
+
+	records := make([]record, 0, len(export.Items))
+	for _, item := range export.Items {
+		r := record{Title: item.Name, Notes: item.Notes, Group: groups[item.FolderID]}
+		if r.Group == "" && len(item.CollectionIDs) > 0 {
+			r.Group = groups[item.CollectionIDs[0]]
+		}
+		if item.Login != nil {
+			r.Username = item.Login.Username
+			r.Password = item.Login.Password
+			r.TOTP = item.Login.TOTP
+			for _, u := range item.Login.URIs {
+				r.URIs = append(r.URIs, u.URI)
+			}
+		}
+		for _, f := range item.Fields {
+			r.setField(f.Name, f.Value)
+		}
+		records = append(records, r)
+	}
+
+	conns, skipped := convert(records)
+	return conns, skipped, nil
+}
+
+// decryptBitwarden decrypts the data of a password-protected export. The key is derived from the
+// password as Bitwarden does for exports, then stretched into an encryption and a MAC key.
+func decryptBitwarden(export *bitwardenExport, password string) ([]byte, error) {
+	if export.KdfIterations <= 0 {
+		return nil, fmt.Errorf("invalid key derivation settings in export")
+	}
+
+	var key []byte
+	var err error
+	switch export.KdfType {
+	case bitwardenPBKDF2:
+		key, err = pbkdf2.Key(sha256.New, password, []byte(export.Salt), export.KdfIterations, 32)
+		if err != nil {
+			return nil, err
+		}
+	case bitwardenArgon2id:
+		if export.KdfMemory <= 0 || export.KdfParallelism <= 0 {
+			return nil, fmt.Errorf("invalid key derivation settings in export")
+		}
+		salt := sha256.Sum256([]byte(export.Salt))
+		key = argon2.IDKey([]byte(password), salt[:], uint32(export.KdfIterations),
+			uint32(export.KdfMemory)*1024, uint8(export.KdfParallelism), 32)
+	default:
+		return nil, fmt.Errorf("unsupported key derivation function %d", export.KdfType)
+	}
+
+	encKey, err := hkdf.Expand(sha256.New, key, "enc", 32)
+	if err != nil {
+		return nil, err
+	}
+	macKey, err := hkdf.Expand(sha256.New, key, "mac", 32)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := decryptEncString(export.KeyValidation, encKey, macKey); err != nil {
+		return nil, ErrWrongPassword
+	}
+	return decryptEncString(export.Data, encKey, macKey)
+}
+
+// decryptEncString decrypts a Bitwarden "2.iv|ciphertext|mac" string: AES-256-CBC with an
+// HMAC-SHA256 over the IV and ciphertext.
+func decryptEncString(s string, encKey, macKey []byte) ([]byte, error) {
+	encType, rest, ok := strings.Cut(s, ".")
+	if !ok || encType != "2" {
+		return nil, fmt.Errorf("unsupported encryption type in export")
+	}
+	parts := strings.Split(rest, "|")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed encrypted value in export")
+	}
+	var decoded [3][]byte
+	for i, p := range parts {
+		b, err := base64.StdEncoding.DecodeString(p)
+		if err != nil {
+			return nil, fmt.Errorf("malformed encrypted value in export: %w", err)
+		}
+		decoded[i] = b
+	}
+	iv, ciphertext, mac := decoded[0], decoded[1], decoded[2]
+
+	h := hmac.New(sha256.New, macKey)
+	h.Write(iv)
+	h.Write(ciphertext)
+	if !hmac.Equal(h.Sum(nil), mac) {
+		return nil, ErrWrongPassword
+	}
+
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != aes.BlockSize || len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("malformed encrypted value in export")
+	}
+	plain := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, ciphertext)
+
+	// PKCS#7 padding
+	pad := int(plain[len(plain)-1])
+	if pad == 0 || pad > aes.BlockSize || !bytes.Equal(plain[len(plain)-pad:], bytes.Repeat([]byte{byte(pad)}, pad)) {
+		return nil, fmt.Errorf("malformed encrypted value in export")
+	}
+	return plain[:len(plain)-pad], nil
+}
@@ -0,0 +1,191 @@
+// Package importer reads RDP connections from the export files of password managers.
+//
+// Each reader turns the file's entries into records and keeps those that name an RDP host, either
+// with an rdp:// URI or with a host field. Folders, or what the password manager has instead,
+// become connection groups.
+package importer
+
+import (
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"rdpctl/model"
+	"rdpctl/totp"
+)
+
+// Skipped describes an entry of an export file that was not turned into a connection.
+type Skipped struct {
+	Title  string
+	Group  string
+	Reason string
+}
+
+// hostFields are the names of custom fields that hold an entry's RDP host, in order of preference.
+var hostFields = []string{"host", "hostname", "server", "address", "computer"}
+
+// record is an entry of an export file, reduced to what a connection needs.
+type record struct {
+	Title    string
+	Group    string
+	Username string
+	Password string
+	Notes    string
+	TOTP     string // Seed or otpauth:// URI
+	URIs     []string
+	Fields   map[string]string // Custom fields by lower-case name
+}
+
+// setField stores a custom field unless the record already has one with the same name.
+func (r *record) setField(name, value string) {
+	if r.Fields == nil {
+		r.Fields = map[string]string{}
+	}
+	name = strings.ToLower(strings.TrimSpace(name))
+	if _, ok := r.Fields[name]; !ok && name != "" {
+		r.Fields[name] = value
+	}
+}
+
+// connection converts the record, or returns why it is not an RDP connection.
+func (r *record) connection() (model.Connection, string) {
+	host, uriUser := "", ""
+	for _, name := range hostFields {
+		value := strings.TrimSpace(r.Fields[name])
+		if value == "" {
+			continue
+		}
+		if h, u, ok := ParseRDPURI(value); ok {
+			host, uriUser = h, u
+			break
+		}
+		// A URL of another kind, such as a web console, is not an RDP host
+		if !strings.Contains(value, "://") {
+			host = value
+			break
+		}
+	}
+	if host == "" {
+		for _, uri := range r.URIs {
+			if h, u, ok := ParseRDPURI(uri); ok {
+				host, uriUser = h, u
+				break
+			}
+		}
+	}
+	if host == "" {
+		return model.Connection{}, "no rdp:// URI or host field"
+	}
+	if port := strings.TrimSpace(r.Fields["port"]); port != "" && port != "3389" {
+		if _, _, err := net.SplitHostPort(host); err != nil {
+			host = net.JoinHostPort(host, port)
+		}
+	}
+
+	now := time.Now()
+	c := model.Connection{
+		ID:        uuid.New().String(),
+		Name:      strings.TrimSpace(r.Title),
+		Host:      host,
+		Group:     strings.Trim(r.Group, "/"),
+		Domain:    strings.TrimSpace(r.Fields["domain"]),
+		Username:  strings.TrimSpace(r.Username),
+		Gateway:   strings.TrimSpace(r.Fields["gateway"]),
+		Notes:     strings.TrimSpace(r.Notes),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if c.Name == "" {
+		c.Name = host
+	}
+	if c.Username == "" {
+		c.Username = strings.TrimSpace(r.Fields["username"])
+	}
+	if c.Username == "" {
+		c.Username = uriUser
+	}
+	// DOMAIN\user in the user name field
+	if domain, user, ok := strings.Cut(c.Username, `\`); ok && c.Domain == "" {
+		c.Domain, c.Username = domain, user
+	}
+
+	// Steam and other non-standard codes cannot be generated, so only keys Parse accepts are kept
+	if _, err := totp.Parse(r.TOTP); err == nil {
+		c.TOTP = strings.TrimSpace(r.TOTP)
+	}
+
+	password := r.Password
+	if password == "" {
+		password = r.Fields["password"]
+	}
+	if password != "" {
+		c.StorePassword = true
+		c.Password = password
+		// The export does not say when the password was set, so its age counts from the import
+		c.PasswordChangedAt = now
+	}
+	return c, ""
+}
+
+// ParseRDPURI returns the host and user name in an rdp:// URI, which is either a URL such as
+// rdp://user@host:port or the Windows form rdp://full%20address=s:host:port&username=s:user.
+// The default port 3389 is dropped. ok is false if raw is not an rdp:// URI.
+func ParseRDPURI(raw string) (host, username string, ok bool) {
+	raw = strings.TrimSpace(raw)
+	scheme, rest, found := strings.Cut(raw, "://")
+	if !found || !strings.EqualFold(scheme, "rdp") {
+		return "", "", false
+	}
+
+	if strings.Contains(rest, "=") {
+		// Settings of a .rdp file as key=type:value pairs
+		for _, pair := range strings.Split(rest, "&") {
+			if unescaped, err := url.QueryUnescape(pair); err == nil {
+				pair = unescaped
+			}
+			key, value, _ := strings.Cut(pair, "=")
+			if _, v, typed := strings.Cut(value, ":"); typed {
+				value = v
+			}
+			switch strings.ToLower(strings.TrimSpace(key)) {
+			case "full address":
+				host = value
+			case "username":
+				username = value
+			}
+		}
+	} else {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return "", "", false
+		}
+		host = u.Host
+		if u.User != nil {
+			username = u.User.Username()
+		}
+	}
+
+	host = strings.TrimSpace(host)
+	if h, port, err := net.SplitHostPort(host); err == nil && port == "3389" && !strings.Contains(h, ":") {
+		host = h
+	}
+	return host, strings.TrimSpace(username), host != ""
+}
+
+// convert turns records into connections, reporting the ones that are not RDP connections.
+func convert(records []record) ([]model.Connection, []Skipped) {
+	var conns []model.Connection
+	var skipped []Skipped
+	for i := range records {
+		c, reason := records[i].connection()
+		if reason != "" {
+			skipped = append(skipped, Skipped{Title: records[i].Title, Group: records[i].Group, Reason: reason})
+			continue
+		}
+		conns = append(conns, c)
+	}
+	return conns, skipped
+}
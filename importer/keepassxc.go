@@ -0,0 +1,70 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"rdpctl/model"
+)
+
+// ReadKeePassXCCSV reads a CSV export of KeePassXC, whose columns are Group, Title, Username,
+// Password, URL, Notes and TOTP. The first group in the Group column is the database itself, so the
+// connection group is the rest of the path. A Host column, if one was added, is also recognized.
+func ReadKeePassXCCSV(r io.Reader) ([]model.Connection, []Skipped, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("not a CSV file: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil, fmt.Errorf("CSV file is empty")
+	}
+
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"title", "url"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("CSV file has no %q column; is it a KeePassXC export?", required)
+		}
+	}
+
+	var records []record
+	var recycled []Skipped
+	for _, row := range rows[1:] {
+		get := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(row) {
+				return row[i]
+			}
+			return ""
+		}
+
+		rec := record{
+			Title:    get("title"),
+			Username: get("username"),
+			Password: get("password"),
+			Notes:    get("notes"),
+			TOTP:     get("totp"),
+			URIs:     []string{get("url")},
+		}
+		if _, path, ok := strings.Cut(get("group"), "/"); ok {
+			rec.Group = path
+		}
+		if rec.Group == "Recycle Bin" || strings.HasPrefix(rec.Group, "Recycle Bin/") {
+			recycled = append(recycled, Skipped{Title: rec.Title, Group: rec.Group, Reason: "in recycle bin"})
+			continue
+		}
+		// Columns added to the export, such as Host or Domain, act as custom fields
+		for name := range columns {
+			if value := get(name); value != "" {
+				rec.setField(name, value)
+			}
+		}
+		records = append(records, rec)
+	}
+
+	conns, skipped := convert(records)
+	return conns, append(skipped, recycled...), nil
+}
@@ -0,0 +1,139 @@
+package importer
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"rdpctl/model"
+)
+
+// Category of 1Password's Server items, whose URL field holds the server address.
+const onePasswordServer = "110"
+
+type onePuxExport struct {
+	Accounts []struct {
+		Vaults []struct {
+			Items []onePuxItem `json:"items"`
+		} `json:"vaults"`
+	} `json:"accounts"`
+}
+
+type onePuxItem struct {
+	State        string `json:"state"`
+	CategoryUUID string `json:"categoryUuid"`
+	Overview     struct {
+		Title string `json:"title"`
+		URL   string `json:"url"`
+		URLs  []struct {
+			URL string `json:"url"`
+		} `json:"urls"`
+		Tags []string `json:"tags"`
+	} `json:"overview"`
+	Details struct {
+		LoginFields []struct {
+			Value       string `json:"value"`
+			Designation string `json:"designation"`
+		} `json:"loginFields"`
+		NotesPlain string `json:"notesPlain"`
+		Password   string `json:"password"`
+		Sections   []struct {
+			Fields []struct {
+				Title string                     `json:"title"`
+				ID    string                     `json:"id"`
+				Value map[string]json.RawMessage `json:"value"`
+			} `json:"fields"`
+		} `json:"sections"`
+	} `json:"details"`
+}
+
+// Read1PUX reads a 1Password .1pux export. 1Password has no folders, so an item's first tag,
+// which it nests with "/" like a folder, becomes the connection group. Archived items are skipped.
+func Read1PUX(path string) ([]model.Connection, []Skipped, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("not a 1Password .1pux export: %w", err)
+	}
+	defer zr.Close()
+
+	f, err := zr.Open("export.data")
+	if err != nil {
+		return nil, nil, fmt.Errorf("not a 1Password .1pux export: %w", err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var export onePuxExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, nil, fmt.Errorf("failed to read export.data: %w", err)
+	}
+
+	var records []record
+	var archived []Skipped
+	for _, account := range export.Accounts {
+		for _, vault := range account.Vaults {
+			for _, item := range vault.Items {
+				r := onePuxRecord(&item)
+				if item.State == "archived" {
+					archived = append(archived, Skipped{Title: r.Title, Group: r.Group, Reason: "archived"})
+					continue
+				}
+				records = append(records, r)
+			}
+		}
+	}
+
+	conns, skipped := convert(records)
+	return conns, append(skipped, archived...), nil
+}
+
+// onePuxRecord converts a 1Password item. Fields in its sections become custom fields.
+func onePuxRecord(item *onePuxItem) record {
+	r := record{Title: item.Overview.Title, Notes: item.Details.NotesPlain, Password: item.Details.Password}
+	if len(item.Overview.Tags) > 0 {
+		r.Group = item.Overview.Tags[0]
+	}
+	if item.Overview.URL != "" {
+		r.URIs = append(r.URIs, item.Overview.URL)
+	}
+	for _, u := range item.Overview.URLs {
+		r.URIs = append(r.URIs, u.URL)
+	}
+	for _, f := range item.Details.LoginFields {
+		switch f.Designation {
+		case "username":
+			r.Username = f.Value
+		case "password":
+			r.Password = f.Value
+		}
+	}
+
+	for _, section := range item.Details.Sections {
+		for _, f := range section.Fields {
+			name := f.Title
+			if name == "" {
+				name = f.ID
+			}
+			// The value is keyed by its kind, such as "string", "concealed", "url" or "totp"
+			for kind, raw := range f.Value {
+				var value string
+				if json.Unmarshal(raw, &value) != nil {
+					continue
+				}
+				if kind == "totp" && r.TOTP == "" {
+					r.TOTP = value
+				}
+				r.setField(name, value)
+				break
+			}
+		}
+	}
+	if item.CategoryUUID == onePasswordServer {
+		r.setField("host", r.Fields["url"])
+	}
+	return r
+}
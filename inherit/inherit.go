@@ -0,0 +1,114 @@
+package inherit
+
+import (
+	"fmt"
+	"strings"
+
+	"rdpctl/model"
+)
+
+// Setting is one effective connection setting and the layer it came from.
+type Setting struct {
+	Field  string
+	Value  string
+	Source string
+}
+
+// layer is one level of the resolution order.
+type layer struct {
+	source   string
+	defaults model.Defaults
+}
+
+// Effective resolves the settings of c in the order vault defaults, group defaults, template,
+// the connection itself, and finally connect-time overrides (which may be nil). For each field the
+// last non-empty value wins. It returns the effective connection and where each setting came from.
+func Effective(v *model.Vault, c *model.Connection, overrides *model.Defaults) (model.Connection, []Setting) {
+	layers := []layer{{source: "defaults", defaults: v.Defaults}}
+
+	if c.Group != "" {
+		if g := FindGroupDefaults(v, c.Group); g != nil {
+			layers = append(layers, layer{source: fmt.Sprintf("group %q", g.Group), defaults: g.Defaults})
+		}
+	}
+	if c.TemplateID != "" {
+		if t := FindTemplate(v, c.TemplateID); t != nil {
+			layers = append(layers, layer{source: fmt.Sprintf("template %q", t.Name), defaults: t.Defaults})
+		}
+	}
+
+	layers = append(layers, layer{source: "connection", defaults: model.Defaults{
+		Domain:    c.Domain,
+		Username:  c.Username,
+		Gateway:   c.Gateway,
+		ExtraArgs: c.ExtraArgs,
+		TOTP:      c.TOTP,
+	}})
+	if overrides != nil {
+		layers = append(layers, layer{source: "override", defaults: *overrides})
+	}
+
+	effective := *c
+	var settings []Setting
+
+	pick := func(field string, get func(model.Defaults) string, set func(string)) {
+		s := Setting{Field: field, Source: "unset"}
+		for _, l := range layers {
+			if value := get(l.defaults); value != "" {
+				s.Value, s.Source = value, l.source
+			}
+		}
+		set(s.Value)
+		settings = append(settings, s)
+	}
+
+	settings = append(settings, Setting{Field: "host", Value: c.Host, Source: "connection"})
+	pick("domain", func(d model.Defaults) string { return d.Domain }, func(s string) { effective.Domain = s })
+	pick("username", func(d model.Defaults) string { return d.Username }, func(s string) { effective.Username = s })
+	pick("gateway", func(d model.Defaults) string { return d.Gateway }, func(s string) { effective.Gateway = s })
+	pick("totp", func(d model.Defaults) string { return d.TOTP }, func(s string) { effective.TOTP = s })
+	if totp := &settings[len(settings)-1]; totp.Value != "" {
+		totp.Value = "(secret)" // Shown by explain, which must not print the seed
+	}
+
+	args := Setting{Field: "extraArgs", Source: "unset"}
+	effective.ExtraArgs = nil
+	for _, l := range layers {
+		if len(l.defaults.ExtraArgs) > 0 {
+			effective.ExtraArgs = l.defaults.ExtraArgs
+			args.Value, args.Source = strings.Join(l.defaults.ExtraArgs, " "), l.source
+		}
+	}
+	settings = append(settings, args)
+
+	return effective, settings
+}
+
+// Inherited returns the settings c would get from its defaults, group and template alone,
+// ignoring its own values. It is used to show what an empty field falls back to.
+func Inherited(v *model.Vault, c *model.Connection) model.Connection {
+	bare := *c
+	bare.Domain, bare.Username, bare.Gateway, bare.ExtraArgs, bare.TOTP = "", "", "", nil, ""
+	effective, _ := Effective(v, &bare, nil)
+	return effective
+}
+
+// FindTemplate returns the template with the given ID, or nil.
+func FindTemplate(v *model.Vault, id string) *model.Template {
+	for i := range v.Templates {
+		if v.Templates[i].ID == id {
+			return &v.Templates[i]
+		}
+	}
+	return nil
+}
+
+// FindGroupDefaults returns the defaults of the named group (case-insensitive), or nil.
+func FindGroupDefaults(v *model.Vault, group string) *model.GroupDefaults {
+	for i := range v.GroupDefaults {
+		if strings.EqualFold(v.GroupDefaults[i].Group, group) {
+			return &v.GroupDefaults[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+// Package kdbx converts between rdpctl connections and KeePass (KDBX 4) databases.
+package kdbx
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tobischo/gokeepasslib/v3"
+	w "github.com/tobischo/gokeepasslib/v3/wrappers"
+
+	"rdpctl/importer"
+	"rdpctl/model"
+	"rdpctl/totp"
+)
+
+// Custom string fields carrying connection settings that KeePass has no standard field for.
+const (
+	fieldDomain        = "Domain"
+	fieldHost          = "Host"
+	fieldGateway       = "Gateway"
+	fieldExtraArgs     = "ExtraArgs" // One argument per line
+	fieldMACAddress    = "MACAddress"
+	fieldPasswordRef   = "PasswordRef"
+	fieldWakeOnConnect = "WakeOnConnect"
+	fieldTOTP          = "otp" // otpauth:// URI, as KeePassXC and KeePassDX store it
+)
+
+// Credentials opens a KeePass database with a password and an optional KeePass keyfile.
+func Credentials(password, keyfile string) (*gokeepasslib.DBCredentials, error) {
+	if keyfile == "" {
+		return gokeepasslib.NewPasswordCredentials(password), nil
+	}
+	return gokeepasslib.NewPasswordAndKeyCredentials(password, keyfile)
+}
+
+// Read decodes a KeePass database and returns a connection for every entry that names an RDP host:
+// an rdp:// URL, a bare host name in the URL field, or a Host field. Group names below the root
+// become the connection's group, joined with "/". Entries in the recycle bin are ignored.
+func Read(r io.Reader, creds *gokeepasslib.DBCredentials) ([]model.Connection, []importer.Skipped, error) {
+	db := gokeepasslib.NewDatabase()
+	db.Credentials = creds
+	if err := gokeepasslib.NewDecoder(r).Decode(db); err != nil {
+		return nil, nil, fmt.Errorf("failed to open KeePass database (wrong password or keyfile?): %w", err)
+	}
+	if err := db.UnlockProtectedEntries(); err != nil {
+		return nil, nil, fmt.Errorf("failed to unlock protected fields: %w", err)
+	}
+
+	var conns []model.Connection
+	var skipped []importer.Skipped
+	recycleBin := db.Content.Meta.RecycleBinUUID
+
+	var walk func(g *gokeepasslib.Group, path []string)
+	walk = func(g *gokeepasslib.Group, path []string) {
+		if db.Content.Meta.RecycleBinEnabled.Bool && g.UUID.Compare(recycleBin) {
+			return
+		}
+		group := strings.Join(path, "/")
+		for i := range g.Entries {
+			c, reason := connectionFromEntry(&g.Entries[i], group)
+			if reason != "" {
+				skipped = append(skipped, importer.Skipped{Title: g.Entries[i].GetTitle(), Group: group, Reason: reason})
+				continue
+			}
+			conns = append(conns, c)
+		}
+		for i := range g.Groups {
+			walk(&g.Groups[i], append(path[:len(path):len(path)], g.Groups[i].Name))
+		}
+	}
+	// The top-level group is the database itself, so its name is not part of connection groups
+	for i := range db.Content.Root.Groups {
+		walk(&db.Content.Root.Groups[i], nil)
+	}
+
+	return conns, skipped, nil
+}
+
+// connectionFromEntry converts one entry, or returns why it is not an RDP connection.
+func connectionFromEntry(e *gokeepasslib.Entry, group string) (model.Connection, string) {
+	host := entryHost(e)
+	if host == "" {
+		return model.Connection{}, "no RDP host"
+	}
+
+	now := time.Now()
+	c := model.Connection{
+		ID:         uuid.New().String(),
+		Name:       strings.TrimSpace(e.GetTitle()),
+		Host:       host,
+		Group:      group,
+		Domain:     strings.TrimSpace(customField(e, fieldDomain)),
+		Username:   strings.TrimSpace(e.GetContent("UserName")),
+		Gateway:    strings.TrimSpace(customField(e, fieldGateway)),
+		MACAddress: strings.TrimSpace(customField(e, fieldMACAddress)),
+		Notes:      strings.TrimSpace(e.GetContent("Notes")),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if c.Name == "" {
+		c.Name = host
+	}
+
+	// DOMAIN\user in the user name field
+	if domain, user, ok := strings.Cut(c.Username, `\`); ok && c.Domain == "" {
+		c.Domain, c.Username = domain, user
+	}
+
+	for _, arg := range strings.Split(customField(e, fieldExtraArgs), "\n") {
+		if arg = strings.TrimSpace(arg); arg != "" {
+			c.ExtraArgs = append(c.ExtraArgs, arg)
+		}
+	}
+	c.WakeOnConnect, _ = strconv.ParseBool(customField(e, fieldWakeOnConnect))
+	if otp := strings.TrimSpace(customField(e, fieldTOTP)); otp != "" {
+		if _, err := totp.Parse(otp); err == nil {
+			c.TOTP = otp
+		}
+	}
+
+	if ref := strings.TrimSpace(customField(e, fieldPasswordRef)); ref != "" {
+		c.PasswordRef = ref
+	} else if password := e.GetPassword(); password != "" {
+		c.StorePassword = true
+		c.Password = password
+		// The entry's last modification is the closest record of when the password was set
+		c.PasswordChangedAt = now
+		if t := e.Times.LastModificationTime; t != nil && !t.Time.IsZero() && t.Time.Before(now) {
+			c.PasswordChangedAt = t.Time
+		}
+	}
+	return c, ""
+}
+
+// entryHost returns the RDP host of an entry, with its port if it is not the default.
+func entryHost(e *gokeepasslib.Entry) string {
+	if host := strings.TrimSpace(customField(e, fieldHost)); host != "" {
+		return host
+	}
+
+	raw := strings.TrimSpace(e.GetContent("URL"))
+	if raw == "" {
+		return ""
+	}
+	if !strings.Contains(raw, "://") {
+		// A bare host or host:port, but not a path or a sentence
+		if strings.ContainsAny(raw, " /") {
+			return ""
+		}
+		return raw
+	}
+
+	host, _, _ := importer.ParseRDPURI(raw)
+	return host
+}
+
+// customField returns the value of a custom string field, matching its name case-insensitively.
+func customField(e *gokeepasslib.Entry, name string) string {
+	for _, v := range e.Values {
+		if strings.EqualFold(v.Key, name) {
+			return v.Value.Content
+		}
+	}
+	return ""
+}
+
+// Write encodes conns as a KDBX 4 database protected by creds. Connections are placed in groups
+// below a top-level "rdpctl" group following their group names, split on "/". Settings without a
+// standard KeePass field are stored in custom fields that Read understands.
+func Write(out io.Writer, conns []model.Connection, creds *gokeepasslib.DBCredentials) error {
+	root := gokeepasslib.NewGroup()
+	root.Name = "rdpctl"
+
+	for _, c := range conns {
+		g := &root
+		if c.Group != "" {
+			for _, name := range strings.Split(c.Group, "/") {
+				g = childGroup(g, name)
+			}
+		}
+		g.Entries = append(g.Entries, entryFromConnection(&c))
+	}
+
+	db := gokeepasslib.NewDatabase(gokeepasslib.WithDatabaseKDBXVersion4())
+	db.Credentials = creds
+	db.Content.Meta.DatabaseName = "rdpctl"
+	db.Content.Root = &gokeepasslib.RootData{Groups: []gokeepasslib.Group{root}}
+
+	if err := db.LockProtectedEntries(); err != nil {
+		return fmt.Errorf("failed to protect fields: %w", err)
+	}
+	if err := gokeepasslib.NewEncoder(out).Encode(db); err != nil {
+		return fmt.Errorf("failed to write KeePass database: %w", err)
+	}
+	return nil
+}
+
+// childGroup returns the subgroup of g with the given name, creating it if needed.
+func childGroup(g *gokeepasslib.Group, name string) *gokeepasslib.Group {
+	for i := range g.Groups {
+		if g.Groups[i].Name == name {
+			return &g.Groups[i]
+		}
+	}
+	child := gokeepasslib.NewGroup()
+	child.Name = name
+	g.Groups = append(g.Groups, child)
+	return &g.Groups[len(g.Groups)-1]
+}
+
+// entryFromConnection converts a connection to a KeePass entry.
+func entryFromConnection(c *model.Connection) gokeepasslib.Entry {
+	e := gokeepasslib.NewEntry()
+	add := func(key, value string, protected bool) {
+		if value == "" && key != "Password" {
+			return
+		}
+		e.Values = append(e.Values, gokeepasslib.ValueData{
+			Key:   key,
+			Value: gokeepasslib.V{Content: value, Protected: w.NewBoolWrapper(protected)},
+		})
+	}
+
+	host := c.Host
+	if h, port, err := net.SplitHostPort(c.Host); err == nil && port == "3389" {
+		host = h
+	}
+
+	add("Title", c.Name, false)
+	add("UserName", c.Username, false)
+	add("Password", c.Password, true)
+	add("URL", "rdp://"+host, false)
+	add("Notes", c.Notes, false)
+	add(fieldDomain, c.Domain, false)
+	add(fieldGateway, c.Gateway, false)
+	add(fieldExtraArgs, strings.Join(c.ExtraArgs, "\n"), false)
+	add(fieldMACAddress, c.MACAddress, false)
+	add(fieldPasswordRef, c.PasswordRef, false)
+	add(fieldTOTP, totpURI(c), true)
+	if c.WakeOnConnect {
+		add(fieldWakeOnConnect, "true", false)
+	}
+	e.Tags = "rdp"
+	return e
+}
+
+// totpURI returns the TOTP key of c as an otpauth:// URI, the form KeePass clients read, or "".
+func totpURI(c *model.Connection) string {
+	key, err := totp.Parse(c.TOTP)
+	if err != nil {
+		return ""
+	}
+	return key.URI(c.Name, c.Username)
+}
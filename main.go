@@ -1,11 +1,24 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"os"
+	"path/filepath"
+	"time"
 
+	"rdpctl/cli"
 	"rdpctl/config"
+	"rdpctl/history"
+	"rdpctl/passwords"
+	"rdpctl/rdp"
+	"rdpctl/trash"
 	"rdpctl/ui"
+	"rdpctl/vault"
+	"rdpctl/wol"
 )
 
 func main() {
@@ -15,8 +28,62 @@ func main() {
 		log.Fatalf("Error ensuring config directory: %v", err)
 	}
 
+	// Global flags come before the subcommand
+	fs := flag.NewFlagSet("rdpctl", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	vaultFlag := fs.String("vault", os.Getenv(config.EnvVault), "vault profile name or vault file path")
+	keyfileFlag := fs.String("keyfile", os.Getenv(config.EnvKeyfile), "keyfile of a vault that requires one")
+	passwordStdin := fs.Bool("password-stdin", false, "read the master password from the first line of stdin")
+	passwordFile := fs.String("password-file", "", "read the master password from the first line of a file")
+	classicMenu := fs.Bool("menu", false, "use the classic menu instead of the full-screen interface")
+	args := os.Args[1:]
+	if err := fs.Parse(args); errors.Is(err, flag.ErrHelp) {
+		args = []string{"help"}
+	} else if err != nil {
+		log.Fatalf("Error: %v", err)
+	} else {
+		args = fs.Args()
+	}
+
 	// Get the full path to the vault file
-	vaultPath := config.VaultPath(configDir)
+	vaultPath, err := config.ResolveVault(configDir, *vaultFlag)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if filepath.Dir(vaultPath) == config.ProfileDir(configDir) {
+		if err := os.MkdirAll(config.ProfileDir(configDir), 0700); err != nil {
+			log.Fatalf("Error creating vault profile directory: %v", err)
+		}
+	}
+
+	// Load global settings; `rdpctl config` can still fix a broken file
+	settingsPath := config.SettingsPath(configDir)
+	settings, err := config.LoadSettings(settingsPath)
+	if err != nil && !(len(args) > 0 && args[0] == "config") {
+		log.Fatalf("Error loading settings: %v", err)
+	}
+	applySettings(settings)
+	ui.KeyfilePath = *keyfileFlag
+
+	// The master password comes from stdin, a file or a command before falling back to the prompt
+	switch {
+	case *passwordStdin && *passwordFile != "":
+		log.Fatalf("Error: --password-stdin and --password-file cannot be combined")
+	case *passwordStdin:
+		ui.MasterPasswordSource = ui.PasswordFromReader(os.Stdin)
+	case *passwordFile != "":
+		ui.MasterPasswordSource = ui.PasswordFromFile(*passwordFile)
+	case settings.Unlock.PasswordCommand != "":
+		ui.MasterPasswordSource = ui.PasswordFromCommand(settings.Unlock.PasswordCommand)
+	}
+
+	// Subcommands run non-interactively and exit without entering the main menu
+	if len(args) > 0 {
+		if err := cli.Run(args, vaultPath, settingsPath); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		return
+	}
 
 	// Run the unlock flow (handles first-time setup and existing vault unlock)
 	v, masterPassword, err := ui.UnlockFlow(vaultPath)
@@ -24,9 +91,56 @@ func main() {
 		log.Fatalf("Error during vault unlock flow: %v", err)
 	}
 
-	// If unlock was successful, enter the main menu loop
-	if err := ui.MainMenu(v, masterPassword, vaultPath); err != nil {
+	// If unlock was successful, enter the full-screen interface, or the main menu loop with --menu
+	if *classicMenu {
+		err = ui.MainMenu(v, masterPassword, vaultPath)
+	} else {
+		err = ui.RunTUI(v, masterPassword, vaultPath)
+	}
+	if err != nil {
 		log.Fatalf("Error during main menu: %v", err)
 	}
 	fmt.Println("Application exited gracefully.")
 }
+
+// applySettings hands the global settings to the packages that use them.
+func applySettings(s config.Settings) {
+	rdp.Launcher = s.Launcher.Binary
+	if s.Launcher.Path != "" {
+		rdp.Launcher = s.Launcher.Path
+	}
+	rdp.BaseArgs = s.Launcher.BaseArgs
+	rdp.DefaultReconnectPolicy = rdp.ReconnectPolicy{
+		MaxAttempts:  s.Reconnect.MaxAttempts,
+		InitialDelay: time.Duration(s.Reconnect.InitialDelay),
+		MaxDelay:     time.Duration(s.Reconnect.MaxDelay),
+		StableAfter:  time.Duration(s.Reconnect.StableAfter),
+	}
+
+	wol.DefaultBroadcast = s.Wake.Broadcast
+	wol.DefaultPort = s.Wake.Port
+	wol.DefaultWaitTimeout = time.Duration(s.Wake.Timeout)
+
+	vault.BackupCount = s.BackupCount
+	history.Limit = s.HistoryLimit
+	trash.Retention = time.Duration(s.TrashDays) * 24 * time.Hour
+	cli.OutputFormat = s.OutputFormat
+	cli.BreachList = s.BreachList
+
+	ui.RecentCount = s.Menu.RecentCount
+	ui.PageSize = s.Menu.PageSize
+	ui.IdleLock = time.Duration(s.IdleLockMinutes) * time.Minute
+	ui.DefaultStagger = time.Duration(s.Workspace.Stagger)
+	ui.MaxUnlockAttempts = s.Unlock.MaxAttempts
+
+	passwords.DefaultOptions = passwords.Options{
+		Length:    s.Generator.Length,
+		Lower:     s.Generator.Lower,
+		Upper:     s.Generator.Upper,
+		Digits:    s.Generator.Digits,
+		Symbols:   s.Generator.Symbols,
+		Words:     s.Generator.Words,
+		Separator: s.Generator.Separator,
+	}
+	passwords.MaxAge = time.Duration(s.PasswordMaxAgeDays) * 24 * time.Hour
+}
@@ -0,0 +1,10 @@
+package model
+
+// AuditState ties the audit log to the vault. The private key decrypts the log entries,
+// and the head records the last entry appended while the vault was unlocked, so that
+// truncating the log is detectable.
+type AuditState struct {
+	PrivateKey []byte `json:"privateKey"`
+	HeadSeq    int    `json:"headSeq"`
+	HeadHash   string `json:"headHash"`
+}
@@ -3,14 +3,30 @@ package model
 import "time"
 
 type Connection struct {
-	ID            string    `json:"id"`
-	Name          string    `json:"name"`
-	Host          string    `json:"host"`
-	Domain        string    `json:"domain"`
-	Username      string    `json:"username"`
-	StorePassword bool      `json:"storePassword"`
-	Password      string    `json:"password,omitempty"`
-	ExtraArgs     []string  `json:"extraArgs,omitempty"`
-	CreatedAt     time.Time `json:"createdAt"`
-	UpdatedAt     time.Time `json:"updatedAt"`
+	ID                string    `json:"id"`
+	Name              string    `json:"name"`
+	Host              string    `json:"host"`
+	Group             string    `json:"group,omitempty"` // Customer or environment the host belongs to
+	Tags              []string  `json:"tags,omitempty"`  // Free-form labels for finding and bulk editing hosts
+	Domain            string    `json:"domain"`
+	Username          string    `json:"username"`
+	StorePassword     bool      `json:"storePassword"`
+	Password          string    `json:"password,omitempty"`
+	PasswordRef       string    `json:"passwordRef,omitempty"`      // Secret store reference resolved at connect time, e.g. pass://work/dc01
+	PasswordChangedAt time.Time `json:"passwordChangedAt,omitzero"` // When the stored password was last set
+	ExtraArgs         []string  `json:"extraArgs,omitempty"`
+	Gateway           string    `json:"gateway,omitempty"`           // RD Gateway host, e.g. gw.example.com
+	TOTP              string    `json:"totp,omitempty"`              // TOTP seed or otpauth:// URI for the gateway's multi-factor prompt
+	TemplateID        string    `json:"templateId,omitempty"`        // Template whose defaults this connection inherits
+	MACAddress        string    `json:"macAddress,omitempty"`        // Wake-on-LAN target, e.g. 00:11:22:33:44:55
+	WakeBroadcast     string    `json:"wakeBroadcast,omitempty"`     // Broadcast address for the magic packet (default 255.255.255.255)
+	WakePort          int       `json:"wakePort,omitempty"`          // UDP port for the magic packet (default 9)
+	WakeOnConnect     bool      `json:"wakeOnConnect,omitempty"`     // Wake the host and wait for RDP before launching
+	ReconnectAttempts *int      `json:"reconnectAttempts,omitempty"` // Overrides the global reconnect limit; 0 disables
+	Notes             string    `json:"notes,omitempty"`
+	LastConnectedAt   time.Time `json:"lastConnectedAt,omitzero"`
+	ConnectCount      int       `json:"connectCount,omitempty"`
+	FrequencyScore    float64   `json:"frequencyScore,omitempty"` // Decayed connect count as of LastConnectedAt
+	CreatedAt         time.Time `json:"createdAt"`
+	UpdatedAt         time.Time `json:"updatedAt"`
 }
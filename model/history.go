@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// ConnectionHistory holds the prior versions of one connection, oldest first.
+type ConnectionHistory struct {
+	ConnectionID string              `json:"connectionId"`
+	Versions     []ConnectionVersion `json:"versions"`
+}
+
+// ConnectionVersion is a connection as it was before an edit replaced it.
+type ConnectionVersion struct {
+	Version    int        `json:"version"`    // Numbered from 1 per connection; the current version is the last one plus 1
+	ReplacedAt time.Time  `json:"replacedAt"` // When the edit that replaced this version was saved
+	Changed    []string   `json:"changed"`    // Fields (by JSON name) the replacing edit changed
+	Connection Connection `json:"connection"`
+}
@@ -0,0 +1,24 @@
+package model
+
+// Defaults holds the connection settings that can be inherited from vault-wide defaults,
+// a group, or a template. Empty values inherit from the next level down.
+type Defaults struct {
+	Domain    string   `json:"domain,omitempty"`
+	Username  string   `json:"username,omitempty"`
+	Gateway   string   `json:"gateway,omitempty"`
+	ExtraArgs []string `json:"extraArgs,omitempty"`
+	TOTP      string   `json:"totp,omitempty"` // Shared by hosts behind the same RD Gateway
+}
+
+// Template is a named set of defaults that connections can opt into.
+type Template struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Defaults Defaults `json:"defaults"`
+}
+
+// GroupDefaults are the defaults shared by every connection in a group.
+type GroupDefaults struct {
+	Group    string   `json:"group"`
+	Defaults Defaults `json:"defaults"`
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// SessionRecord is a timesheet entry for one RDP session.
+type SessionRecord struct {
+	ID             string    `json:"id"`
+	ConnectionID   string    `json:"connectionId"`
+	ConnectionName string    `json:"connectionName"` // Name of the connection when the session started
+	Group          string    `json:"group,omitempty"`
+	Start          time.Time `json:"start"`
+	End            time.Time `json:"end,omitzero"`            // Zero while the session is running or if EndUnknown
+	EndUnknown     bool      `json:"endUnknown,omitempty"`    // Ended at a time that was not recorded; only AdjustMinutes counts
+	AdjustMinutes  int       `json:"adjustMinutes,omitempty"` // Manual correction added to the measured duration
+	Note           string    `json:"note,omitempty"`
+	PID            int       `json:"pid,omitempty"`          // Process to watch for the end of the session
+	ProcessStart   uint64    `json:"processStart,omitempty"` // Start time of that process, to tell a reused PID apart
+	LogFile        string    `json:"logFile,omitempty"`      // Background session log, whose last write marks the end
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// TrashedConnection is a deleted connection kept in the vault's trash until it is restored or purged.
+type TrashedConnection struct {
+	Connection  Connection          `json:"connection"`
+	DeletedAt   time.Time           `json:"deletedAt"`
+	Memberships []TrashedMembership `json:"memberships,omitempty"` // Workspaces the connection was removed from
+}
+
+// TrashedMembership records a trashed connection's place in a workspace, so a restore can put it back.
+type TrashedMembership struct {
+	WorkspaceID string          `json:"workspaceId"`
+	Member      WorkspaceMember `json:"member"`
+}
@@ -1,6 +1,18 @@
 package model
 
 type Vault struct {
-	Version     int          `json:"version"`
-	Connections []Connection `json:"connections"`
+	Version       int                 `json:"version"`
+	Connections   []Connection        `json:"connections"`
+	Workspaces    []Workspace         `json:"workspaces,omitempty"`
+	Defaults      Defaults            `json:"defaults,omitzero"` // Vault-wide connection defaults
+	GroupDefaults []GroupDefaults     `json:"groupDefaults,omitempty"`
+	Templates     []Template          `json:"templates,omitempty"`
+	Variables     map[string]string   `json:"variables,omitempty"` // Referenced from connection fields as ${var:name}
+	SortOrder     string              `json:"sortOrder,omitempty"` // Order of the connection selector (see usage.SortOrder)
+	Audit         *AuditState         `json:"audit,omitempty"`
+	Sessions      []SessionRecord     `json:"sessions,omitempty"` // Timesheet of past and running sessions
+	History       []ConnectionHistory `json:"history,omitempty"`  // Prior versions of edited connections
+	Trash         []TrashedConnection `json:"trash,omitempty"`    // Deleted connections awaiting restore or purge
+
+	KeyfileHash []byte `json:"-"` // SHA-256 of the keyfile required to unlock the vault; kept in the file header, not the JSON
 }
@@ -0,0 +1,17 @@
+package model
+
+// Workspace is a named, ordered set of connections that are opened together.
+type Workspace struct {
+	ID             string            `json:"id"`
+	Name           string            `json:"name"`
+	Members        []WorkspaceMember `json:"members"`
+	StaggerSeconds *int              `json:"staggerSeconds,omitempty"` // Delay between launching members; nil for the default, 0 for none
+}
+
+// WorkspaceMember references a connection in a workspace, with optional launch overrides.
+type WorkspaceMember struct {
+	ConnectionID string   `json:"connectionId"`
+	Monitor      string   `json:"monitor,omitempty"`   // xfreerdp /monitors: value, e.g. "1" or "0,1"
+	Size         string   `json:"size,omitempty"`      // xfreerdp /size: value, e.g. "1920x1080"
+	ExtraArgs    []string `json:"extraArgs,omitempty"` // Appended after the connection's own extra arguments
+}
@@ -0,0 +1,38 @@
+package passwords
+
+import (
+	"time"
+
+	"rdpctl/model"
+)
+
+// MaxAge is how old a stored password may get before it is reported as stale. 0 disables the check.
+var MaxAge time.Duration
+
+// Stale returns the connections of v whose stored password is older than MaxAge at now.
+// Passwords stored before change dates were recorded have no date and are not reported.
+func Stale(v *model.Vault, now time.Time) []*model.Connection {
+	var stale []*model.Connection
+	for i := range v.Connections {
+		if IsStale(&v.Connections[i], now) {
+			stale = append(stale, &v.Connections[i])
+		}
+	}
+	return stale
+}
+
+// IsStale reports whether the stored password of c is older than MaxAge at now.
+func IsStale(c *model.Connection, now time.Time) bool {
+	if MaxAge <= 0 || !c.StorePassword || c.Password == "" || c.PasswordChangedAt.IsZero() {
+		return false
+	}
+	return now.Sub(c.PasswordChangedAt) > MaxAge
+}
+
+// Age returns how long ago the stored password of c was changed, or 0 if that is unknown.
+func Age(c *model.Connection, now time.Time) time.Duration {
+	if !c.StorePassword || c.PasswordChangedAt.IsZero() {
+		return 0
+	}
+	return now.Sub(c.PasswordChangedAt)
+}
@@ -0,0 +1,129 @@
+// Package passwords generates passwords and passphrases and tracks how old stored passwords are.
+package passwords
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/sethvargo/go-diceware/diceware"
+)
+
+// Character classes of generated passwords.
+const (
+	lowerChars  = "abcdefghijklmnopqrstuvwxyz"
+	upperChars  = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digitChars  = "0123456789"
+	symbolChars = "!@#$%^&*()-_=+[]{};:,.?/~"
+)
+
+// effWords is the size of the EFF long word list passphrases are drawn from.
+const effWords = 7776
+
+// Options configure Generate. When Words is positive a passphrase of that many words joined by
+// Separator is generated and the other fields are ignored.
+type Options struct {
+	Length    int
+	Lower     bool
+	Upper     bool
+	Digits    bool
+	Symbols   bool
+	Words     int
+	Separator string
+}
+
+// DefaultOptions are used by the generator in the menu and the defaults of `rdpctl genpass`.
+var DefaultOptions = Options{Length: 24, Lower: true, Upper: true, Digits: true, Symbols: true, Separator: "-"}
+
+// Generate returns a random password or passphrase. A password contains at least one character of
+// every enabled class.
+func Generate(opts Options) (string, error) {
+	if opts.Words > 0 {
+		words, err := diceware.Generate(opts.Words)
+		if err != nil {
+			return "", err
+		}
+		return strings.Join(words, opts.Separator), nil
+	}
+
+	classes := opts.classes()
+	if len(classes) == 0 {
+		return "", fmt.Errorf("enable at least one character class")
+	}
+	if opts.Length < len(classes) {
+		return "", fmt.Errorf("length must be at least %d to include every character class", len(classes))
+	}
+
+	// One character from each class, the rest from all of them, then shuffled
+	all := strings.Join(classes, "")
+	chars := make([]byte, 0, opts.Length)
+	for _, class := range classes {
+		c, err := randomChar(class)
+		if err != nil {
+			return "", err
+		}
+		chars = append(chars, c)
+	}
+	for len(chars) < opts.Length {
+		c, err := randomChar(all)
+		if err != nil {
+			return "", err
+		}
+		chars = append(chars, c)
+	}
+	for i := len(chars) - 1; i > 0; i-- {
+		j, err := randomInt(i + 1)
+		if err != nil {
+			return "", err
+		}
+		chars[i], chars[j] = chars[j], chars[i]
+	}
+	return string(chars), nil
+}
+
+// Entropy returns the strength of passwords generated with opts, in bits.
+func Entropy(opts Options) float64 {
+	if opts.Words > 0 {
+		return float64(opts.Words) * math.Log2(effWords)
+	}
+	size := len(strings.Join(opts.classes(), ""))
+	if size == 0 {
+		return 0
+	}
+	return float64(opts.Length) * math.Log2(float64(size))
+}
+
+func (opts Options) classes() []string {
+	var classes []string
+	if opts.Lower {
+		classes = append(classes, lowerChars)
+	}
+	if opts.Upper {
+		classes = append(classes, upperChars)
+	}
+	if opts.Digits {
+		classes = append(classes, digitChars)
+	}
+	if opts.Symbols {
+		classes = append(classes, symbolChars)
+	}
+	return classes
+}
+
+func randomChar(set string) (byte, error) {
+	i, err := randomInt(len(set))
+	if err != nil {
+		return 0, err
+	}
+	return set[i], nil
+}
+
+func randomInt(n int) (int, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(i.Int64()), nil
+}
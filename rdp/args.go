@@ -2,17 +2,35 @@ package rdp
 
 import (
 	"fmt"
+	"net"
 	"strings"
 
+	"rdpctl/expand"
 	"rdpctl/model"
+	"rdpctl/security"
 )
 
+// Launcher is the RDP client executable: a name looked up in PATH or a full path.
+var Launcher = "xfreerdp"
+
+// BaseArgs are placed before every connection's own arguments.
+var BaseArgs = []string{
+	"+clipboard",          // Enable clipboard redirection
+	"+dynamic-resolution", // Enable dynamic resolution updates
+}
+
 // BuildArgs constructs the arguments slice for the xfreerdp command.
-func BuildArgs(c *model.Connection, password string) []string {
-	args := []string{
-		"+clipboard",         // Enable clipboard redirection
-		"+dynamic-resolution", // Enable dynamic resolution updates
+// References such as ${HOME}, ${var:name} and ${conn:field} in the connection's host, domain,
+// username, gateway and extra arguments are expanded first, using vars as the vault variables.
+// The password is passed through untouched.
+func BuildArgs(c *model.Connection, password string, vars map[string]string) ([]string, error) {
+	expanded, err := expand.Connection(c, vars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand connection settings: %w", err)
 	}
+	c = &expanded
+
+	args := append([]string{}, BaseArgs...)
 
 	// Mandatory arguments
 	args = append(args, fmt.Sprintf("/v:%s", c.Host))
@@ -22,6 +40,10 @@ func BuildArgs(c *model.Connection, password string) []string {
 		args = append(args, fmt.Sprintf("/d:%s", c.Domain))
 	}
 
+	if c.Gateway != "" {
+		args = append(args, fmt.Sprintf("/g:%s", c.Gateway))
+	}
+
 	// Handle password storage
 	if c.StorePassword {
 		// If password is stored in the vault, use it directly.
@@ -37,19 +59,23 @@ func BuildArgs(c *model.Connection, password string) []string {
 		args = append(args, c.ExtraArgs...)
 	}
 
-	return args
+	return args, nil
 }
 
-// SanitizeArgsForDisplay removes sensitive information (like passwords) from the argument list
-// before displaying or logging them.
+// SanitizeArgsForDisplay masks the values of options that carry credentials, such as passwords
+// and gateway tokens (see security.MaskArgs), before displaying or logging the arguments.
 func SanitizeArgsForDisplay(args []string) []string {
-	sanitizedArgs := make([]string, 0, len(args))
-	for _, arg := range args {
-		if strings.HasPrefix(arg, "/p:") {
-			sanitizedArgs = append(sanitizedArgs, "/p:********")
-		} else {
-			sanitizedArgs = append(sanitizedArgs, arg)
-		}
+	return security.MaskArgs(args)
+}
+
+// DefaultPort is the standard RDP listening port.
+const DefaultPort = "3389"
+
+// Address returns the host:port the RDP client will connect to, defaulting the port to 3389
+// when the connection's host does not specify one.
+func Address(c *model.Connection) string {
+	if _, _, err := net.SplitHostPort(c.Host); err == nil {
+		return c.Host
 	}
-	return sanitizedArgs
+	return net.JoinHostPort(strings.Trim(c.Host, "[]"), DefaultPort)
 }
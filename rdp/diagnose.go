@@ -0,0 +1,144 @@
+package rdp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Errors describing why xfreerdp failed. ClientError unwraps to one of these, so callers
+// can test for a cause with errors.Is.
+var (
+	ErrLogonFailure      = errors.New("the username or password was rejected")
+	ErrAccountLocked     = errors.New("the account is locked out")
+	ErrAccountDisabled   = errors.New("the account is disabled or expired")
+	ErrPasswordExpired   = errors.New("the password has expired and must be changed")
+	ErrAccessDenied      = errors.New("the account is not allowed to log on to this host")
+	ErrDNSFailure        = errors.New("the host name could not be resolved")
+	ErrCertificate       = errors.New("the server certificate does not match the one previously seen for this host")
+	ErrNLARequired       = errors.New("the server requires Network Level Authentication (NLA)")
+	ErrNegotiationFailed = errors.New("security protocol negotiation with the server failed")
+	ErrConnectionFailed  = errors.New("the connection to the host failed or was lost")
+	ErrKDCUnreachable    = errors.New("the Kerberos KDC could not be reached")
+	ErrInvalidArguments  = errors.New("xfreerdp rejected its command-line arguments")
+	ErrClientFailed      = errors.New("xfreerdp exited with an error")
+)
+
+// ClientError describes a failed xfreerdp run.
+type ClientError struct {
+	Cause    error  // One of the Err* values above
+	ExitCode int    // xfreerdp exit status
+	Line     string // The client output line that identified the cause, if any
+}
+
+func (e *ClientError) Error() string {
+	msg := fmt.Sprintf("%v (exit status %d)", e.Cause, e.ExitCode)
+	if e.Line != "" {
+		msg += fmt.Sprintf(": %s", e.Line)
+	}
+	return msg
+}
+
+func (e *ClientError) Unwrap() error {
+	return e.Cause
+}
+
+// exitCodeCauses maps xfreerdp exit codes to a failure cause.
+var exitCodeCauses = map[int]error{
+	9:   ErrAccessDenied,      // XF_EXIT_USER_PRIVILEGES
+	128: ErrInvalidArguments,  // XF_EXIT_PARSE_ARGUMENTS
+	131: ErrConnectionFailed,  // XF_EXIT_CONN_FAILED
+	132: ErrLogonFailure,      // XF_EXIT_AUTH_FAILURE
+	133: ErrNegotiationFailed, // XF_EXIT_NEGO_FAILURE
+	134: ErrLogonFailure,      // XF_EXIT_LOGON_FAILURE
+	135: ErrAccountLocked,     // XF_EXIT_ACCOUNT_LOCKED_OUT
+	139: ErrDNSFailure,        // XF_EXIT_DNS_ERROR
+	140: ErrDNSFailure,        // XF_EXIT_DNS_NAME_NOT_FOUND
+	141: ErrConnectionFailed,  // XF_EXIT_CONNECT_FAILED
+	143: ErrConnectionFailed,  // XF_EXIT_TLS_CONNECT_FAILED
+	144: ErrAccessDenied,      // XF_EXIT_INSUFFICIENT_PRIVILEGES
+	147: ErrConnectionFailed,  // XF_EXIT_CONNECT_TRANSPORT_FAILED
+	148: ErrPasswordExpired,   // XF_EXIT_CONNECT_PASSWORD_EXPIRED
+	149: ErrPasswordExpired,   // XF_EXIT_CONNECT_PASSWORD_MUST_CHANGE
+	150: ErrKDCUnreachable,    // XF_EXIT_CONNECT_KDC_UNREACHABLE
+	151: ErrAccountDisabled,   // XF_EXIT_CONNECT_ACCOUNT_DISABLED
+	152: ErrPasswordExpired,   // XF_EXIT_CONNECT_PASSWORD_CERTAINLY_EXPIRED
+	154: ErrLogonFailure,      // XF_EXIT_CONNECT_WRONG_PASSWORD
+	155: ErrAccessDenied,      // XF_EXIT_CONNECT_ACCESS_DENIED
+	156: ErrAccessDenied,      // XF_EXIT_CONNECT_ACCOUNT_RESTRICTION
+	157: ErrAccountDisabled,   // XF_EXIT_CONNECT_ACCOUNT_EXPIRED
+	158: ErrAccessDenied,      // XF_EXIT_CONNECT_LOGON_TYPE_NOT_GRANTED
+}
+
+// outputPatterns maps substrings of xfreerdp's log output to a failure cause. They are checked
+// in order, so more specific patterns come first.
+var outputPatterns = []struct {
+	pattern string
+	cause   error
+}{
+	{"CERTIFICATE NAME MISMATCH", ErrCertificate},
+	{"WARNING: REMOTE HOST IDENTIFICATION HAS CHANGED", ErrCertificate},
+	{"certificate verification failure", ErrCertificate},
+	{"HYBRID_REQUIRED_BY_SERVER", ErrNLARequired},
+	{"ACCOUNT_LOCKED_OUT", ErrAccountLocked},
+	{"ACCOUNT_DISABLED", ErrAccountDisabled},
+	{"ACCOUNT_EXPIRED", ErrAccountDisabled},
+	{"PASSWORD_EXPIRED", ErrPasswordExpired},
+	{"PASSWORD_MUST_CHANGE", ErrPasswordExpired},
+	{"PASSWORD_CERTAINLY_EXPIRED", ErrPasswordExpired},
+	{"STATUS_LOGON_FAILURE", ErrLogonFailure},
+	{"ERRCONNECT_LOGON_FAILURE", ErrLogonFailure},
+	{"ERRCONNECT_WRONG_PASSWORD", ErrLogonFailure},
+	{"ERRCONNECT_AUTHENTICATION_FAILED", ErrLogonFailure},
+	{"ERRCONNECT_DNS_NAME_NOT_FOUND", ErrDNSFailure},
+	{"ERRCONNECT_DNS_ERROR", ErrDNSFailure},
+	{"Name or service not known", ErrDNSFailure},
+	{"nodename nor servname provided", ErrDNSFailure},
+	{"ERRCONNECT_KDC_UNREACHABLE", ErrKDCUnreachable},
+}
+
+// Diagnose turns an xfreerdp exit code and the client's captured output into a ClientError.
+// A recognized output line takes precedence over the exit code, since it is usually more specific.
+func Diagnose(exitCode int, output string) *ClientError {
+	for _, line := range strings.Split(output, "\n") {
+		for _, p := range outputPatterns {
+			if strings.Contains(line, p.pattern) {
+				return &ClientError{Cause: p.cause, ExitCode: exitCode, Line: strings.TrimSpace(line)}
+			}
+		}
+	}
+
+	if cause, ok := exitCodeCauses[exitCode]; ok {
+		return &ClientError{Cause: cause, ExitCode: exitCode}
+	}
+	return &ClientError{Cause: ErrClientFailed, ExitCode: exitCode}
+}
+
+// tailBuffer is an io.Writer that keeps the last max bytes written to it.
+type tailBuffer struct {
+	mu   sync.Mutex
+	max  int
+	data []byte
+}
+
+func newTailBuffer(max int) *tailBuffer {
+	return &tailBuffer{max: max}
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.data = append(b.data, p...)
+	if len(b.data) > b.max {
+		b.data = append([]byte(nil), b.data[len(b.data)-b.max:]...)
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.data)
+}
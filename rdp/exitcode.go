@@ -0,0 +1,48 @@
+package rdp
+
+// xfreerdp exit codes (see XF_EXIT_CODE in FreeRDP's client/X11/xfreerdp.h).
+const (
+	ExitSuccess                = 0
+	ExitDisconnect             = 1
+	ExitLogoff                 = 2
+	ExitIdleTimeout            = 3
+	ExitLogonTimeout           = 4
+	ExitConnReplaced           = 5
+	ExitDisconnectByUser       = 11
+	ExitConnFailed             = 131
+	ExitDNSError               = 139
+	ExitConnectFailed          = 141
+	ExitTLSConnectFailed       = 143
+	ExitConnectTransportFailed = 147
+	ExitKDCUnreachable         = 150
+)
+
+// ExitKind groups xfreerdp exit codes by how rdpctl should react to them.
+type ExitKind int
+
+const (
+	// ExitKindUser is a normal end of session initiated by the user or the server (disconnect, logoff).
+	ExitKindUser ExitKind = iota
+	// ExitKindNetwork is a network or transport failure worth retrying.
+	ExitKindNetwork
+	// ExitKindError is any other failure; retrying would not help.
+	ExitKindError
+)
+
+// ClassifyExit reports what kind of exit an xfreerdp exit code represents.
+// A negative code means the process was terminated by a signal, which is treated as user-initiated.
+func ClassifyExit(code int) ExitKind {
+	if code < 0 {
+		return ExitKindUser
+	}
+
+	switch code {
+	case ExitSuccess, ExitDisconnect, ExitLogoff, ExitDisconnectByUser, ExitConnReplaced:
+		return ExitKindUser
+	case ExitConnFailed, ExitDNSError, ExitConnectFailed, ExitTLSConnectFailed,
+		ExitConnectTransportFailed, ExitKDCUnreachable:
+		return ExitKindNetwork
+	default:
+		return ExitKindError
+	}
+}
@@ -0,0 +1,53 @@
+package rdp
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"rdpctl/model"
+)
+
+// ReconnectPolicy controls how Run relaunches the client after a network failure.
+type ReconnectPolicy struct {
+	MaxAttempts  int           // Consecutive relaunches before giving up; 0 disables reconnecting
+	InitialDelay time.Duration // Delay before the first relaunch
+	MaxDelay     time.Duration // Upper bound for the exponentially growing delay
+	StableAfter  time.Duration // A session that lasted this long resets the attempt counter
+}
+
+// DefaultReconnectPolicy applies to connections that do not set their own attempt limit.
+// The RDPCTL_RECONNECT_ATTEMPTS environment variable overrides its MaxAttempts.
+var DefaultReconnectPolicy = ReconnectPolicy{
+	MaxAttempts:  5,
+	InitialDelay: 2 * time.Second,
+	MaxDelay:     time.Minute,
+	StableAfter:  time.Minute,
+}
+
+// PolicyFor returns the reconnect policy for c, combining the global default with the
+// connection's own attempt limit.
+func PolicyFor(c *model.Connection) ReconnectPolicy {
+	policy := DefaultReconnectPolicy
+
+	if env := os.Getenv("RDPCTL_RECONNECT_ATTEMPTS"); env != "" {
+		if n, err := strconv.Atoi(env); err == nil && n >= 0 {
+			policy.MaxAttempts = n
+		}
+	}
+
+	if c.ReconnectAttempts != nil {
+		policy.MaxAttempts = *c.ReconnectAttempts
+	}
+
+	return policy
+}
+
+// nextDelay doubles delay, capped at the policy's maximum.
+func (p ReconnectPolicy) nextDelay(delay time.Duration) time.Duration {
+	delay *= 2
+	if delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
@@ -1,33 +1,132 @@
 package rdp
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"syscall"
+	"time"
 
 	"rdpctl/model"
 )
 
 // Run executes the xfreerdp command with the given connection and password.
-func Run(c *model.Connection, password string) error {
+// When the client exits because of a network or transport failure, it is relaunched with
+// exponential backoff according to the connection's reconnect policy. Disconnects and logoffs
+// initiated by the user or server end the session without an error.
+func Run(c *model.Connection, password string, vars map[string]string) error {
+	policy := PolicyFor(c)
+	delay := policy.InitialDelay
+	attempts := 0
+
+	for {
+		started := time.Now()
+		code, err := runOnce(c, password, vars)
+		if err == nil {
+			return nil
+		}
+		if code == exitNotStarted {
+			return err
+		}
+
+		switch ClassifyExit(code) {
+		case ExitKindUser:
+			fmt.Printf("Session to %s ended (exit status %d).\n", c.Name, code)
+			return nil
+		case ExitKindError:
+			return err
+		}
+		if !retryable(err) {
+			return err
+		}
+
+		// A session that stayed up for a while starts a fresh series of attempts.
+		if time.Since(started) >= policy.StableAfter {
+			attempts = 0
+			delay = policy.InitialDelay
+		}
+
+		if attempts >= policy.MaxAttempts {
+			if policy.MaxAttempts == 0 {
+				return err
+			}
+			return fmt.Errorf("giving up after %d reconnect attempts: %w", attempts, err)
+		}
+		attempts++
+
+		fmt.Printf("Connection to %s lost (exit status %d). Reconnecting in %s (attempt %d of %d)...\n",
+			c.Name, code, delay, attempts, policy.MaxAttempts)
+		time.Sleep(delay)
+		delay = policy.nextDelay(delay)
+	}
+}
+
+// retryable reports whether a failure is a transient network problem worth reconnecting after.
+func retryable(err error) bool {
+	return errors.Is(err, ErrConnectionFailed) || errors.Is(err, ErrDNSFailure) || errors.Is(err, ErrKDCUnreachable)
+}
+
+// outputTailSize is how much of the client's output is kept for diagnosing failures.
+const outputTailSize = 64 * 1024
+
+// exitNotStarted is returned by runOnce when xfreerdp could not be started at all.
+const exitNotStarted = -2
+
+// runOnce runs xfreerdp in the foreground a single time and returns its exit code.
+func runOnce(c *model.Connection, password string, vars map[string]string) (int, error) {
 	// Build the arguments for xfreerdp
-	args := BuildArgs(c, password)
+	args, err := BuildArgs(c, password, vars)
+	if err != nil {
+		return exitNotStarted, err
+	}
 
 	// Print the sanitized command for user information (excluding sensitive data)
-	fmt.Printf("Running xfreerdp %s\n", SanitizeArgsForDisplay(args))
+	fmt.Printf("Running %s %s\n", Launcher, SanitizeArgsForDisplay(args))
 
-	cmd := exec.Command("xfreerdp", args...)
+	cmd := exec.Command(Launcher, args...)
 
-	// Attach stdin, stdout, and stderr to the current process
+	// Attach stdin, stdout, and stderr to the current process, keeping the tail of the
+	// client's output so a failure can be diagnosed
+	output := newTailBuffer(outputTailSize)
 	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stdout = io.MultiWriter(os.Stdout, output)
+	cmd.Stderr = io.MultiWriter(os.Stderr, output)
 
 	// Run the command
-	err := cmd.Run()
+	err = cmd.Run()
+	if err == nil {
+		return ExitSuccess, nil
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), Diagnose(exitErr.ExitCode(), output.String())
+	}
+	return exitNotStarted, fmt.Errorf("%s command failed: %w", Launcher, err)
+}
+
+// Start launches xfreerdp detached from the terminal, writing its output to logFile.
+// The client runs in its own session so it survives the menu being interrupted or closed.
+// The caller is responsible for waiting on the returned command.
+func Start(c *model.Connection, password string, vars map[string]string, logFile *os.File) (*exec.Cmd, error) {
+	args, err := BuildArgs(c, password, vars)
 	if err != nil {
-		return fmt.Errorf("xfreerdp command failed: %w", err)
+		return nil, err
+	}
+
+	fmt.Fprintf(logFile, "Running %s %s\n", Launcher, SanitizeArgsForDisplay(args))
+
+	cmd := exec.Command(Launcher, args...)
+	cmd.Stdin = nil
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", Launcher, err)
 	}
 
-	return nil
+	return cmd, nil
 }
@@ -0,0 +1,115 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register("pass", passProvider{})
+	Register("bw", bitwardenProvider{})
+	Register("op", onePasswordProvider{})
+}
+
+// commandTimeout bounds password manager CLIs, which may wait for a passphrase or unlock prompt.
+const commandTimeout = 2 * time.Minute
+
+// runCommand runs a password manager CLI and returns its output. Stdin stays attached to the
+// terminal so the tool can prompt; its error output is included in the returned error.
+func runCommand(ctx context.Context, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %w: %s", name, err, msg)
+		}
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return stdout.String(), nil
+}
+
+// firstLine returns the first line of output without its line ending.
+func firstLine(output string) string {
+	line, _, _ := strings.Cut(output, "\n")
+	return strings.TrimRight(line, "\r")
+}
+
+// passProvider resolves pass://path with the standard Unix password manager. The password is the
+// first line of the entry; pass://path#key instead returns the value of a "key: value" line.
+type passProvider struct{}
+
+func (passProvider) Resolve(ctx context.Context, ref Reference) (string, error) {
+	output, err := runCommand(ctx, "pass", "show", ref.Path)
+	if err != nil {
+		return "", err
+	}
+	if ref.Fragment == "" {
+		return firstLine(output), nil
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(key), ref.Fragment) {
+			return strings.TrimSpace(value), nil
+		}
+	}
+	return "", fmt.Errorf("entry %s has no %q line", ref.Path, ref.Fragment)
+}
+
+// bitwardenProvider resolves bw://item-id with the Bitwarden CLI, which must be unlocked
+// (BW_SESSION set). bw://item-id#field returns a custom field instead of the password.
+type bitwardenProvider struct{}
+
+func (bitwardenProvider) Resolve(ctx context.Context, ref Reference) (string, error) {
+	if ref.Fragment == "" {
+		output, err := runCommand(ctx, "bw", "get", "password", ref.Path)
+		if err != nil {
+			return "", err
+		}
+		return firstLine(output), nil
+	}
+
+	output, err := runCommand(ctx, "bw", "get", "item", ref.Path)
+	if err != nil {
+		return "", err
+	}
+	var item struct {
+		Fields []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal([]byte(output), &item); err != nil {
+		return "", fmt.Errorf("failed to parse Bitwarden item: %w", err)
+	}
+	for _, f := range item.Fields {
+		if strings.EqualFold(f.Name, ref.Fragment) {
+			return f.Value, nil
+		}
+	}
+	return "", fmt.Errorf("item %s has no field %q", ref.Path, ref.Fragment)
+}
+
+// onePasswordProvider resolves op://vault/item/field with the 1Password CLI, which reads
+// secret references of exactly this form.
+type onePasswordProvider struct{}
+
+func (onePasswordProvider) Resolve(ctx context.Context, ref Reference) (string, error) {
+	output, err := runCommand(ctx, "op", "read", "--no-newline", strings.TrimSpace(ref.Raw))
+	if err != nil {
+		return "", err
+	}
+	return output, nil
+}
@@ -0,0 +1,146 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register("vault", &VaultProvider{})
+}
+
+// VaultProvider resolves vault://mount/path#key from HashiCorp Vault over its HTTP API. The key
+// defaults to "password". KV version 2 mounts work with or without "data/" in the path.
+//
+// The server and token come from VAULT_ADDR and VAULT_TOKEN (or ~/.vault-token), as with the
+// vault CLI, unless Address and Token are set; VAULT_NAMESPACE selects an Enterprise namespace.
+type VaultProvider struct {
+	Address string
+	Token   string
+	Client  *http.Client
+}
+
+// errVaultNotFound is returned by read for a path with no secret.
+var errVaultNotFound = errors.New("no secret at this path")
+
+func (p *VaultProvider) Resolve(ctx context.Context, ref Reference) (string, error) {
+	path := strings.Trim(ref.Path, "/")
+	data, err := p.read(ctx, path)
+	if errors.Is(err, errVaultNotFound) && !strings.Contains(path, "/data/") {
+		// KV version 2 stores secrets under <mount>/data/<path>
+		if mount, rest, ok := strings.Cut(path, "/"); ok {
+			data, err = p.read(ctx, mount+"/data/"+rest)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	key := ref.Fragment
+	if key == "" {
+		key = "password"
+	}
+	value, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no key %q", path, key)
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("key %q of secret %s is not a string", key, path)
+	}
+	return s, nil
+}
+
+// read fetches the secret at path and returns its key/value pairs, unwrapping KV version 2 responses.
+func (p *VaultProvider) read(ctx context.Context, path string) (map[string]interface{}, error) {
+	address, token, err := p.credentials()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(address, "/")+"/v1/"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if ns := os.Getenv("VAULT_NAMESPACE"); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+
+	client := p.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errVaultNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Errors []string `json:"errors"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && len(apiErr.Errors) > 0 {
+			return nil, fmt.Errorf("vault returned %s: %s", resp.Status, strings.Join(apiErr.Errors, "; "))
+		}
+		return nil, fmt.Errorf("vault returned %s", resp.Status)
+	}
+
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return nil, fmt.Errorf("failed to parse vault response: %w", err)
+	}
+
+	// KV version 2 nests the secret under data.data next to data.metadata
+	if inner, ok := secret.Data["data"].(map[string]interface{}); ok {
+		if _, ok := secret.Data["metadata"]; ok {
+			return inner, nil
+		}
+	}
+	return secret.Data, nil
+}
+
+// credentials returns the server address and token to use.
+func (p *VaultProvider) credentials() (string, string, error) {
+	address := p.Address
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	if address == "" {
+		return "", "", fmt.Errorf("VAULT_ADDR is not set")
+	}
+
+	token := p.Token
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	if token == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			if data, err := os.ReadFile(filepath.Join(home, ".vault-token")); err == nil {
+				token = strings.TrimSpace(string(data))
+			}
+		}
+	}
+	if token == "" {
+		return "", "", fmt.Errorf("no vault token: set VAULT_TOKEN or log in with the vault CLI")
+	}
+	return address, token, nil
+}
@@ -0,0 +1,46 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func init() {
+	Register("env", envProvider{})
+	Register("file", fileProvider{})
+}
+
+// envProvider resolves env://NAME to the value of an environment variable.
+type envProvider struct{}
+
+func (envProvider) Resolve(ctx context.Context, ref Reference) (string, error) {
+	value, ok := os.LookupEnv(ref.Path)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", ref.Path)
+	}
+	return value, nil
+}
+
+// fileProvider resolves file://path to the first line of a file. A leading ~/ is the home directory.
+type fileProvider struct{}
+
+func (fileProvider) Resolve(ctx context.Context, ref Reference) (string, error) {
+	path := ref.Path
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, rest)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	line, _, _ := strings.Cut(string(data), "\n")
+	return strings.TrimRight(line, "\r"), nil
+}
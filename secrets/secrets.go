@@ -0,0 +1,76 @@
+// Package secrets resolves references to passwords kept in external secret stores, such as
+// pass://work/dc01 or vault://secret/rdp/dc01#password, at connect time.
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Reference is a parsed secret reference of the form scheme://path#fragment.
+type Reference struct {
+	Raw      string // The reference as written
+	Scheme   string // Selects the provider, e.g. "pass"
+	Path     string // Provider-specific location of the secret
+	Fragment string // Optional field within the secret
+}
+
+// Provider resolves references for one scheme. New backends implement it and call Register.
+type Provider interface {
+	Resolve(ctx context.Context, ref Reference) (string, error)
+}
+
+// providers maps a scheme to the provider resolving it.
+var providers = map[string]Provider{}
+
+// Register makes p resolve references with the given scheme, replacing any earlier provider.
+func Register(scheme string, p Provider) {
+	providers[scheme] = p
+}
+
+// Schemes returns the registered schemes in alphabetical order.
+func Schemes() []string {
+	schemes := make([]string, 0, len(providers))
+	for scheme := range providers {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// Parse splits a reference into its parts and checks that a provider handles its scheme.
+func Parse(raw string) (Reference, error) {
+	scheme, rest, ok := strings.Cut(strings.TrimSpace(raw), "://")
+	if !ok || scheme == "" || rest == "" {
+		return Reference{}, fmt.Errorf("invalid secret reference %q: expected scheme://path", raw)
+	}
+	if _, ok := providers[scheme]; !ok {
+		return Reference{}, fmt.Errorf("unknown secret store %q (supported: %s)", scheme, strings.Join(Schemes(), ", "))
+	}
+
+	ref := Reference{Raw: raw, Scheme: scheme}
+	ref.Path, ref.Fragment, _ = strings.Cut(rest, "#")
+	if ref.Path == "" {
+		return Reference{}, fmt.Errorf("invalid secret reference %q: missing path", raw)
+	}
+	return ref, nil
+}
+
+// Resolve fetches the secret a reference points to.
+func Resolve(ctx context.Context, raw string) (string, error) {
+	ref, err := Parse(raw)
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := providers[ref.Scheme].Resolve(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", ref.Raw, err)
+	}
+	if secret == "" {
+		return "", fmt.Errorf("failed to resolve %s: the secret is empty", ref.Raw)
+	}
+	return secret, nil
+}
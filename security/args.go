@@ -0,0 +1,68 @@
+package security
+
+import "strings"
+
+// riskyOption is a client option that weakens the connection.
+type riskyOption struct {
+	prefix   string
+	severity Severity
+	detail   string
+}
+
+// insecureOptions are matched case-insensitively against the start of each argument.
+var insecureOptions = []riskyOption{
+	{"/cert-ignore", High, "server certificate is not verified"},
+	{"/cert:ignore", High, "server certificate is not verified"},
+	{"/sec:rdp", High, "legacy RDP security without TLS or NLA"},
+	{"-sec-nla", Medium, "network level authentication is disabled"},
+	{"/sec-nla:off", Medium, "network level authentication is disabled"},
+	{"/tls-seclevel:0", Medium, "weak TLS ciphers are allowed"},
+	{"/cert:tofu", Low, "server certificate is trusted on first use"},
+	{"/cert-tofu", Low, "server certificate is trusted on first use"},
+}
+
+// secretOptions carry credentials, which in ExtraArgs are stored unencrypted in the vault's
+// display and visible to other users in the process list.
+var secretOptions = []riskyOption{
+	{"/p:", High, "password"},
+	{"/gp:", High, "gateway password"},
+	{"/pth:", High, "password hash"},
+	{"/gat:", High, "gateway access token"},
+}
+
+// checkArgs reports insecure options and secrets among client arguments.
+func checkArgs(args []string) []Finding {
+	var findings []Finding
+	for _, arg := range args {
+		lower := strings.ToLower(strings.TrimSpace(arg))
+		for _, o := range insecureOptions {
+			if strings.HasPrefix(lower, o.prefix) {
+				findings = append(findings, Finding{Check: CheckInsecureOption, Severity: o.severity, Detail: arg + ": " + o.detail})
+			}
+		}
+		for _, o := range secretOptions {
+			if strings.HasPrefix(lower, o.prefix) {
+				// Never repeat the secret itself
+				findings = append(findings, Finding{Check: CheckSecretInArgs, Severity: o.severity,
+					Detail: o.prefix + "... puts a " + o.detail + " in the arguments, unmasked and visible in the process list"})
+			}
+		}
+	}
+	return findings
+}
+
+// MaskArgs returns a copy of args with the values of options that carry credentials masked,
+// for showing arguments on screen.
+func MaskArgs(args []string) []string {
+	masked := make([]string, len(args))
+	for i, arg := range args {
+		masked[i] = arg
+		lower := strings.ToLower(strings.TrimSpace(arg))
+		for _, o := range secretOptions {
+			if strings.HasPrefix(lower, o.prefix) {
+				masked[i] = strings.TrimSpace(arg)[:len(o.prefix)] + "********"
+			}
+		}
+	}
+	return masked
+}
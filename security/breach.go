@@ -0,0 +1,163 @@
+package security
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// searchWindow is the span of the breach list file below which binary search gives way to a scan.
+const searchWindow = 64 * 1024
+
+// BreachList is an offline copy of the Have I Been Pwned Pwned Passwords list in SHA-1 format.
+// It is either one file of HASH:COUNT lines ordered by hash, or a directory of k-anonymity range
+// files named after the first five hex digits of the hash (such as 5BAA6 or 5BAA6.txt), each
+// holding SUFFIX:COUNT lines as returned by the range API.
+type BreachList struct {
+	path string
+	dir  bool
+}
+
+// OpenBreachList checks that path is a breach list file or directory.
+func OpenBreachList(path string) (*BreachList, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("breach list: %w", err)
+	}
+	return &BreachList{path: path, dir: info.IsDir()}, nil
+}
+
+// Count returns how often password appears in the breach list, or 0 if it does not.
+func (b *BreachList) Count(password string) (int, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	if b.dir {
+		return b.countInRange(hash)
+	}
+	return b.countInFile(hash)
+}
+
+// countInRange looks the hash up in the range file of its five-digit prefix. A missing range file
+// means no breached password has that prefix.
+func (b *BreachList) countInRange(hash string) (int, error) {
+	prefix, suffix := hash[:5], hash[5:]
+	for _, name := range []string{prefix, prefix + ".txt", strings.ToLower(prefix), strings.ToLower(prefix) + ".txt"} {
+		f, err := os.Open(filepath.Join(b.path, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		defer f.Close()
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			// Range files hold suffixes, but accept full hashes too
+			if count, ok := matchLine(scanner.Text(), suffix, hash); ok {
+				return count, nil
+			}
+		}
+		return 0, scanner.Err()
+	}
+	return 0, nil
+}
+
+// countInFile binary searches a breach list file ordered by hash, then scans the last window.
+func (b *BreachList) countInFile(hash string) (int, error) {
+	f, err := os.Open(b.path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	// The line holding hash, if any, starts in [lo, hi)
+	lo, hi := int64(0), info.Size()
+	for hi-lo > searchWindow {
+		mid := lo + (hi-lo)/2
+		start, line, err := lineAfter(f, mid)
+		if err != nil {
+			return 0, err
+		}
+		if start >= hi || line == "" {
+			hi = mid + 1
+			continue
+		}
+		lineHash, _, _ := strings.Cut(line, ":")
+		switch c := strings.Compare(strings.ToUpper(lineHash), hash); {
+		case c == 0:
+			count, _ := matchLine(line, hash, hash)
+			return count, nil
+		case c < 0:
+			lo = start + int64(len(line)) + 1
+		default:
+			hi = start
+		}
+	}
+
+	if _, err := f.Seek(lo, io.SeekStart); err != nil {
+		return 0, err
+	}
+	scanner := bufio.NewScanner(io.LimitReader(f, hi-lo+128))
+	for scanner.Scan() {
+		if count, ok := matchLine(scanner.Text(), hash, hash); ok {
+			return count, nil
+		}
+	}
+	return 0, scanner.Err()
+}
+
+// lineAfter returns the first complete line starting at or after offset, and where it starts.
+func lineAfter(f *os.File, offset int64) (int64, string, error) {
+	start := offset
+	if offset > 0 {
+		start-- // A line starting exactly at offset follows the newline before it
+	}
+	buf := make([]byte, 512)
+	n, err := f.ReadAt(buf, start)
+	if err != nil && err != io.EOF {
+		return 0, "", err
+	}
+	buf = buf[:n]
+
+	if offset > 0 {
+		nl := bytes.IndexByte(buf, '\n')
+		if nl < 0 {
+			return start + int64(n), "", nil
+		}
+		start += int64(nl) + 1
+		buf = buf[nl+1:]
+	}
+	if end := bytes.IndexByte(buf, '\n'); end >= 0 {
+		buf = buf[:end]
+	}
+	return start, strings.TrimRight(string(buf), "\r"), nil
+}
+
+// matchLine parses a HASH:COUNT line and reports whether its hash is want or full.
+func matchLine(line, want, full string) (int, bool) {
+	lineHash, count, found := strings.Cut(strings.TrimSpace(line), ":")
+	if !found {
+		return 0, false
+	}
+	lineHash = strings.ToUpper(lineHash)
+	if lineHash != want && lineHash != full {
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || n < 1 {
+		n = 1
+	}
+	return n, true
+}
@@ -0,0 +1,85 @@
+package security
+
+import (
+	"fmt"
+	"math"
+	"unicode"
+)
+
+// Entropy below which a stored password is reported, in bits.
+const (
+	weakEntropy     = 40
+	moderateEntropy = 60
+)
+
+// Entropy estimates the strength of a password in bits: its length times log2 of the size of the
+// character classes it uses. Repeated characters and runs such as "abc" or "321" count as a single
+// character, since guessing tools try them early.
+func Entropy(password string) float64 {
+	var lower, upper, digit, symbol, other bool
+	length := 0
+	var prev, delta rune
+	for i, r := range password {
+		switch {
+		case r < unicode.MaxASCII && unicode.IsLower(r):
+			lower = true
+		case r < unicode.MaxASCII && unicode.IsUpper(r):
+			upper = true
+		case r < unicode.MaxASCII && unicode.IsDigit(r):
+			digit = true
+		case r < unicode.MaxASCII:
+			symbol = true
+		default:
+			other = true
+		}
+
+		if i > 0 {
+			d := r - prev
+			prev = r
+			if d == 0 || ((d == 1 || d == -1) && d == delta) {
+				delta = d
+				continue
+			}
+			delta = d
+		} else {
+			prev = r
+		}
+		length++
+	}
+
+	pool := 0
+	for _, class := range []struct {
+		used bool
+		size int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {symbol, 33}, {other, 100}} {
+		if class.used {
+			pool += class.size
+		}
+	}
+	if pool == 0 {
+		return 0
+	}
+	return float64(length) * math.Log2(float64(pool))
+}
+
+// checkStrength reports a password whose estimated entropy is too low.
+func checkStrength(password string) (Finding, bool) {
+	bits := Entropy(password)
+	f := Finding{Check: CheckWeakPassword, Detail: fmt.Sprintf("estimated %.0f bits", bits)}
+	switch {
+	case bits < weakEntropy:
+		f.Severity = High
+	case bits < moderateEntropy:
+		f.Severity = Medium
+	default:
+		return Finding{}, false
+	}
+	return f, true
+}
+
+func pluralize(n int, one, many string) string {
+	if n == 1 {
+		return fmt.Sprintf(one, n)
+	}
+	return fmt.Sprintf(many, n)
+}
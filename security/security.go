@@ -0,0 +1,148 @@
+// Package security audits a vault for risky passwords and client options.
+package security
+
+import (
+	"sort"
+	"strings"
+
+	"rdpctl/inherit"
+	"rdpctl/model"
+)
+
+// Severity ranks how risky a finding is.
+type Severity string
+
+const (
+	High   Severity = "high"
+	Medium Severity = "medium"
+	Low    Severity = "low"
+)
+
+// penalties is what each finding of a severity takes off the score.
+var penalties = map[Severity]int{High: 20, Medium: 10, Low: 3}
+
+// Checks that produce findings.
+const (
+	CheckWeakPassword     = "weak-password"
+	CheckReusedPassword   = "reused-password"
+	CheckBreachedPassword = "breached-password"
+	CheckInsecureOption   = "insecure-option"
+	CheckSecretInArgs     = "secret-in-args"
+)
+
+// Finding is one risk found in the vault. Connection is empty for risks in the global settings.
+type Finding struct {
+	Connection string   `json:"connection,omitempty"`
+	Check      string   `json:"check"`
+	Severity   Severity `json:"severity"`
+	Detail     string   `json:"detail"`
+}
+
+// Report is the result of Audit.
+type Report struct {
+	Score       int       `json:"score"` // 100 with no findings, down to 0
+	Connections int       `json:"connections"`
+	Passwords   int       `json:"storedPasswords"`
+	BreachList  bool      `json:"breachListChecked"`
+	Findings    []Finding `json:"findings"`
+}
+
+// Count returns the number of findings with the given severity.
+func (r *Report) Count(s Severity) int {
+	n := 0
+	for _, f := range r.Findings {
+		if f.Severity == s {
+			n++
+		}
+	}
+	return n
+}
+
+// Audit checks the stored passwords and effective client arguments of every connection in v, and
+// baseArgs, the arguments every connection starts with. breaches may be nil to skip the breach
+// list check.
+func Audit(v *model.Vault, baseArgs []string, breaches *BreachList) (*Report, error) {
+	r := &Report{Connections: len(v.Connections), BreachList: breaches != nil, Findings: []Finding{}}
+
+	for _, f := range checkArgs(baseArgs) {
+		f.Detail += " (launcher.baseArgs setting)"
+		r.Findings = append(r.Findings, f)
+	}
+
+	byPassword := map[string][]string{}
+	for i := range v.Connections {
+		c := &v.Connections[i]
+
+		effective, settings := inherit.Effective(v, c, nil)
+		source := ""
+		for _, s := range settings {
+			if s.Field == "extraArgs" && s.Source != "connection" {
+				source = " (inherited from " + s.Source + ")"
+			}
+		}
+		for _, f := range checkArgs(effective.ExtraArgs) {
+			f.Connection = c.Name
+			f.Detail += source
+			r.Findings = append(r.Findings, f)
+		}
+
+		if !c.StorePassword || c.Password == "" {
+			continue
+		}
+		r.Passwords++
+		byPassword[c.Password] = append(byPassword[c.Password], c.Name)
+
+		if f, weak := checkStrength(c.Password); weak {
+			f.Connection = c.Name
+			r.Findings = append(r.Findings, f)
+		}
+		if breaches != nil {
+			count, err := breaches.Count(c.Password)
+			if err != nil {
+				return nil, err
+			}
+			if count > 0 {
+				r.Findings = append(r.Findings, Finding{
+					Connection: c.Name,
+					Check:      CheckBreachedPassword,
+					Severity:   High,
+					Detail:     pluralize(count, "appears %d time", "appears %d times") + " in the breach list",
+				})
+			}
+		}
+	}
+
+	for _, names := range byPassword {
+		if len(names) < 2 {
+			continue
+		}
+		for i, name := range names {
+			others := append(append([]string{}, names[:i]...), names[i+1:]...)
+			r.Findings = append(r.Findings, Finding{
+				Connection: name,
+				Check:      CheckReusedPassword,
+				Severity:   Medium,
+				Detail:     "same password as " + strings.Join(others, ", "),
+			})
+		}
+	}
+
+	order := map[Severity]int{High: 0, Medium: 1, Low: 2}
+	sort.SliceStable(r.Findings, func(i, j int) bool {
+		a, b := r.Findings[i], r.Findings[j]
+		if a.Severity != b.Severity {
+			return order[a.Severity] < order[b.Severity]
+		}
+		if a.Connection != b.Connection {
+			return a.Connection < b.Connection
+		}
+		return a.Check < b.Check
+	})
+
+	r.Score = 100
+	for _, f := range r.Findings {
+		r.Score -= penalties[f.Severity]
+	}
+	r.Score = max(r.Score, 0)
+	return r, nil
+}
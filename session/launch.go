@@ -0,0 +1,68 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"rdpctl/config"
+	"rdpctl/model"
+	"rdpctl/rdp"
+)
+
+// Launch starts the connection's RDP client in the background and records it in the store.
+// The entry is removed again once the client exits, as long as this process is still running;
+// otherwise it is pruned as stale the next time the store is listed.
+func (s *Store) Launch(c *model.Connection, password string, vars map[string]string) (*Session, error) {
+	configDir, err := config.EnsureConfigDir()
+	if err != nil {
+		return nil, err
+	}
+
+	logDir := config.LogDir(configDir)
+	if err := os.MkdirAll(logDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create log directory %s: %w", logDir, err)
+	}
+
+	startedAt := time.Now()
+	logPath := filepath.Join(logDir, fmt.Sprintf("%s-%s.log", c.ID, startedAt.Format("20060102-150405")))
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open session log: %w", err)
+	}
+
+	cmd, err := rdp.Start(c, password, vars, logFile)
+	if err != nil {
+		logFile.Close()
+		return nil, err
+	}
+
+	// Without a start time, because the client has already exited again or the platform does not
+	// provide one, the session is checked by its PID alone
+	proc, _ := Identify(cmd.Process.Pid)
+	sess := Session{
+		ConnectionID: c.ID,
+		PID:          cmd.Process.Pid,
+		StartTime:    proc.StartTime,
+		StartedAt:    startedAt,
+		LogFile:      logPath,
+	}
+	if err := s.Add(sess); err != nil {
+		// An untracked client could not be listed or killed, so stop it again
+		syscall.Kill(-sess.PID, syscall.SIGTERM)
+		cmd.Wait()
+		logFile.Close()
+		return nil, err
+	}
+
+	// Reap the client so it does not linger as a zombie, and forget it once it exits.
+	go func() {
+		cmd.Wait()
+		logFile.Close()
+		s.Remove(sess.PID)
+	}()
+
+	return &sess, nil
+}
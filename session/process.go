@@ -0,0 +1,43 @@
+package session
+
+import (
+	"errors"
+	"syscall"
+)
+
+// Process identifies a process by its PID and start time, so that a process that was given the
+// same PID later, after the original exited or the system rebooted, is not mistaken for it.
+type Process struct {
+	PID       int
+	StartTime uint64 // In a platform-specific unit, see startTime; 0 if it could not be read
+}
+
+// Identify returns the identity of the running process with the given PID. If its start time
+// cannot be read, the error is returned along with an identity that has no start time.
+func Identify(pid int) (Process, error) {
+	start, err := startTime(pid)
+	if err != nil {
+		return Process{PID: pid}, err
+	}
+	return Process{PID: pid, StartTime: start}, nil
+}
+
+// Alive reports whether p is still running. When the start time of p or of the process now
+// using its PID cannot be read, it falls back to checking that some process has the PID.
+func (p Process) Alive() bool {
+	if p.PID <= 0 {
+		return false
+	}
+	if p.StartTime != 0 {
+		if start, err := startTime(p.PID); err == nil {
+			return start == p.StartTime
+		}
+	}
+	return signalable(p.PID)
+}
+
+// signalable reports whether a process with the given PID exists, by sending it signal 0.
+func signalable(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,16 @@
+//go:build darwin
+
+package session
+
+import "golang.org/x/sys/unix"
+
+// startTime reads the start time of a process, in microseconds since the Unix epoch, with the
+// kern.proc.pid sysctl.
+func startTime(pid int) (uint64, error) {
+	info, err := unix.SysctlKinfoProc("kern.proc.pid", pid)
+	if err != nil {
+		return 0, err
+	}
+	tv := info.Proc.P_starttime
+	return uint64(tv.Sec)*1e6 + uint64(tv.Usec), nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package session
+
+import "errors"
+
+// startTime is not implemented on this platform, so Process.Alive checks the PID alone.
+func startTime(pid int) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
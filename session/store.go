@@ -0,0 +1,167 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"rdpctl/config"
+)
+
+// Session describes a background RDP client started by rdpctl.
+type Session struct {
+	ConnectionID string    `json:"connectionId"`
+	PID          int       `json:"pid"`
+	StartTime    uint64    `json:"startTime,omitempty"` // Process start time, see Process
+	StartedAt    time.Time `json:"startedAt"`
+	LogFile      string    `json:"logFile"`
+}
+
+// Process returns the identity of the session's client process.
+func (s Session) Process() Process {
+	return Process{PID: s.PID, StartTime: s.StartTime}
+}
+
+// Store persists running sessions in a JSON state file shared by all rdpctl processes.
+type Store struct {
+	path string
+}
+
+// NewStore returns a store backed by the state file at path.
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// DefaultStore returns the store in the rdpctl configuration directory.
+func DefaultStore() (*Store, error) {
+	configDir, err := config.EnsureConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewStore(config.SessionsPath(configDir)), nil
+}
+
+// List returns all sessions whose client is still running, dropping stale entries from the state
+// file, including entries whose PID now belongs to another process.
+func (s *Store) List() ([]Session, error) {
+	var live []Session
+	err := s.update(func(sessions []Session) []Session {
+		live = live[:0]
+		for _, sess := range sessions {
+			if sess.Process().Alive() {
+				live = append(live, sess)
+			}
+		}
+		return live
+	})
+	return live, err
+}
+
+// Add records a newly started session.
+func (s *Store) Add(sess Session) error {
+	return s.update(func(sessions []Session) []Session {
+		return append(sessions, sess)
+	})
+}
+
+// Remove drops the session with the given PID.
+func (s *Store) Remove(pid int) error {
+	return s.update(func(sessions []Session) []Session {
+		kept := sessions[:0]
+		for _, sess := range sessions {
+			if sess.PID != pid {
+				kept = append(kept, sess)
+			}
+		}
+		return kept
+	})
+}
+
+// update applies fn to the stored sessions under an exclusive file lock and writes the result back.
+func (s *Store) update(fn func([]Session) []Session) error {
+	lock, err := os.OpenFile(s.path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open session lock: %w", err)
+	}
+	defer lock.Close()
+
+	if err := syscall.Flock(int(lock.Fd()), syscall.LOCK_EX); err != nil {
+		return fmt.Errorf("failed to lock session state: %w", err)
+	}
+	defer syscall.Flock(int(lock.Fd()), syscall.LOCK_UN)
+
+	sessions, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	return s.write(fn(sessions))
+}
+
+func (s *Store) read() ([]Session, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session state: %w", err)
+	}
+
+	var sessions []Session
+	if err := json.Unmarshal(data, &sessions); err != nil {
+		return nil, fmt.Errorf("failed to parse session state: %w", err)
+	}
+	return sessions, nil
+}
+
+func (s *Store) write(sessions []Session) error {
+	if sessions == nil {
+		sessions = []Session{}
+	}
+	data, err := json.MarshalIndent(sessions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal session state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".sessions-*")
+	if err != nil {
+		return fmt.Errorf("failed to write session state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write session state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write session state: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace session state: %w", err)
+	}
+	return nil
+}
+
+// Alive reports whether the process with the given PID is still the one that started at
+// startTime (see Process).
+func Alive(pid int, startTime uint64) bool {
+	return Process{PID: pid, StartTime: startTime}.Alive()
+}
+
+// Kill asks the session's client to terminate. The client runs in its own session, so the
+// signal goes to its whole process group. Nothing is signalled if the PID no longer belongs
+// to the client.
+func Kill(sess Session) error {
+	if !sess.Process().Alive() {
+		return fmt.Errorf("the session with PID %d has already ended", sess.PID)
+	}
+	if err := syscall.Kill(-sess.PID, syscall.SIGTERM); err != nil {
+		return fmt.Errorf("failed to signal process %d: %w", sess.PID, err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package timesheet
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"rdpctl/model"
+)
+
+// WriteCSV writes one row per session with its group, connection, times, adjustment and hours.
+func WriteCSV(w io.Writer, sessions []*model.SessionRecord, now time.Time) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"id", "group", "connection", "start", "end", "adjust_minutes", "hours", "note"}); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+
+	for _, rec := range sessions {
+		end := ""
+		if rec.EndUnknown {
+			end = "unknown"
+		} else if !rec.End.IsZero() {
+			end = rec.End.Format(time.RFC3339)
+		}
+		row := []string{
+			rec.ID,
+			rec.Group,
+			rec.ConnectionName,
+			rec.Start.Format(time.RFC3339),
+			end,
+			strconv.Itoa(rec.AdjustMinutes),
+			FormatHours(Duration(rec, now)),
+			rec.Note,
+		}
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("failed to write CSV: %w", err)
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
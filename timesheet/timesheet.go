@@ -0,0 +1,172 @@
+package timesheet
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"rdpctl/model"
+)
+
+// Start adds a running session for c to the vault's timesheet and returns its ID.
+// pid is the process whose exit ends the session and processStart its start time (see
+// session.Process); logFile, if set, is written until it ends.
+func Start(v *model.Vault, c *model.Connection, now time.Time, pid int, processStart uint64, logFile string) string {
+	rec := model.SessionRecord{
+		ID:             uuid.New().String(),
+		ConnectionID:   c.ID,
+		ConnectionName: c.Name,
+		Group:          c.Group,
+		Start:          now,
+		PID:            pid,
+		ProcessStart:   processStart,
+		LogFile:        logFile,
+	}
+	v.Sessions = append(v.Sessions, rec)
+	return rec.ID
+}
+
+// Finish marks the session with the given ID as ended at now.
+func Finish(v *model.Vault, id string, now time.Time) {
+	if rec := Find(v, id); rec != nil {
+		rec.End = now
+		rec.PID = 0
+		rec.ProcessStart = 0
+		rec.LogFile = ""
+	}
+}
+
+// Running reports whether the session has not ended yet.
+func Running(rec *model.SessionRecord) bool {
+	return rec.End.IsZero() && !rec.EndUnknown
+}
+
+// NeedsAdjustment reports whether the session ended at an unknown time and its length has not
+// been entered with `rdpctl report adjust` yet, so it counts no time.
+func NeedsAdjustment(rec *model.SessionRecord) bool {
+	return rec.EndUnknown && rec.AdjustMinutes == 0
+}
+
+// Reconcile ends running sessions whose process has exited, for example background sessions or a
+// menu that was closed mid-session. alive reports whether the process with the given PID and start
+// time still runs. The end time is the last write to the session's log when there is one, and
+// otherwise unknown, in which case the session is flagged with EndUnknown until its length is
+// entered with `rdpctl report adjust`. It reports whether any session was changed.
+func Reconcile(v *model.Vault, alive func(pid int, startTime uint64) bool) bool {
+	changed := false
+	for i := range v.Sessions {
+		rec := &v.Sessions[i]
+		if !Running(rec) || alive(rec.PID, rec.ProcessStart) {
+			continue
+		}
+
+		if info, err := os.Stat(rec.LogFile); rec.LogFile != "" && err == nil && info.ModTime().After(rec.Start) {
+			rec.End = info.ModTime()
+		} else {
+			rec.EndUnknown = true
+		}
+		rec.PID = 0
+		rec.ProcessStart = 0
+		rec.LogFile = ""
+		changed = true
+	}
+	return changed
+}
+
+// Find returns the session whose ID equals id or, if unique, starts with it.
+func Find(v *model.Vault, id string) *model.SessionRecord {
+	var match *model.SessionRecord
+	for i := range v.Sessions {
+		if v.Sessions[i].ID == id {
+			return &v.Sessions[i]
+		}
+		if strings.HasPrefix(v.Sessions[i].ID, id) {
+			if match != nil {
+				return nil
+			}
+			match = &v.Sessions[i]
+		}
+	}
+	return match
+}
+
+// Duration returns the billable duration of a session: its measured length plus any manual
+// adjustment, never negative. Running sessions are measured up to now; a session whose end is
+// unknown has no measured length.
+func Duration(rec *model.SessionRecord, now time.Time) time.Duration {
+	var d time.Duration
+	switch {
+	case Running(rec):
+		d = now.Sub(rec.Start)
+	case !rec.EndUnknown:
+		d = rec.End.Sub(rec.Start)
+	}
+	d += time.Duration(rec.AdjustMinutes) * time.Minute
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// Filter selects sessions that started in [From, To) and, if Group is set, belong to that group.
+type Filter struct {
+	From  time.Time
+	To    time.Time
+	Group string
+}
+
+// Select returns the sessions matching f, in the order they were recorded.
+func Select(v *model.Vault, f Filter) []*model.SessionRecord {
+	var selected []*model.SessionRecord
+	for i := range v.Sessions {
+		rec := &v.Sessions[i]
+		if !f.From.IsZero() && rec.Start.Before(f.From) {
+			continue
+		}
+		if !f.To.IsZero() && !rec.Start.Before(f.To) {
+			continue
+		}
+		if f.Group != "" && !strings.EqualFold(rec.Group, f.Group) {
+			continue
+		}
+		selected = append(selected, rec)
+	}
+	return selected
+}
+
+// Total is the time spent on one group.
+type Total struct {
+	Group    string
+	Sessions int
+	Duration time.Duration
+}
+
+// Totals sums the durations of sessions per group, in order of first appearance.
+// Sessions without a group are totalled under "(none)".
+func Totals(sessions []*model.SessionRecord, now time.Time) []Total {
+	var totals []Total
+	index := map[string]int{}
+	for _, rec := range sessions {
+		group := rec.Group
+		if group == "" {
+			group = "(none)"
+		}
+		i, ok := index[group]
+		if !ok {
+			i = len(totals)
+			index[group] = i
+			totals = append(totals, Total{Group: group})
+		}
+		totals[i].Sessions++
+		totals[i].Duration += Duration(rec, now)
+	}
+	return totals
+}
+
+// FormatHours formats a duration as decimal hours, the usual unit for billing.
+func FormatHours(d time.Duration) string {
+	return fmt.Sprintf("%.2f", d.Hours())
+}
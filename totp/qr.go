@@ -0,0 +1,39 @@
+package totp
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"  // Register GIF for image.Decode
+	_ "image/jpeg" // Register JPEG for image.Decode
+	_ "image/png"  // Register PNG for image.Decode
+	"os"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+)
+
+// ReadQR decodes the QR code in a PNG, JPEG or GIF image, such as a screenshot of the code shown
+// when setting up an authenticator, and returns its text.
+func ReadQR(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return "", fmt.Errorf("failed to read image %s: %w", path, err)
+	}
+	bmp, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return "", err
+	}
+	result, err := qrcode.NewQRCodeReader().Decode(bmp, map[gozxing.DecodeHintType]interface{}{
+		gozxing.DecodeHintType_TRY_HARDER: true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("no QR code found in %s", path)
+	}
+	return result.GetText(), nil
+}
@@ -0,0 +1,137 @@
+// Package totp generates RFC 6238 time-based one-time passwords, such as the codes an RD Gateway
+// asks for after the password.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Key is a TOTP secret with the parameters codes are generated with.
+type Key struct {
+	Secret    []byte
+	Algorithm string // SHA1, SHA256 or SHA512
+	Digits    int
+	Period    time.Duration
+}
+
+// Parse reads a TOTP key from a base32 seed, as shown next to the QR code when setting up an
+// authenticator, or from an otpauth://totp/ URI, which is what the QR code holds.
+func Parse(s string) (*Key, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, fmt.Errorf("empty TOTP seed")
+	}
+	if !strings.HasPrefix(strings.ToLower(s), "otpauth:") {
+		secret, err := decodeSecret(s)
+		if err != nil {
+			return nil, err
+		}
+		return &Key{Secret: secret, Algorithm: "SHA1", Digits: 6, Period: 30 * time.Second}, nil
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid otpauth URI: %w", err)
+	}
+	if !strings.EqualFold(u.Host, "totp") {
+		return nil, fmt.Errorf("only time-based (otpauth://totp/) codes are supported, not %q", u.Host)
+	}
+	q := u.Query()
+
+	secret, err := decodeSecret(q.Get("secret"))
+	if err != nil {
+		return nil, err
+	}
+	k := &Key{Secret: secret, Algorithm: "SHA1", Digits: 6, Period: 30 * time.Second}
+
+	if alg := strings.ToUpper(q.Get("algorithm")); alg != "" {
+		if alg != "SHA1" && alg != "SHA256" && alg != "SHA512" {
+			return nil, fmt.Errorf("unsupported TOTP algorithm %q", alg)
+		}
+		k.Algorithm = alg
+	}
+	if d := q.Get("digits"); d != "" {
+		n, err := strconv.Atoi(d)
+		if err != nil || n < 6 || n > 8 {
+			return nil, fmt.Errorf("invalid TOTP digits %q", d)
+		}
+		k.Digits = n
+	}
+	if p := q.Get("period"); p != "" {
+		n, err := strconv.Atoi(p)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid TOTP period %q", p)
+		}
+		k.Period = time.Duration(n) * time.Second
+	}
+	return k, nil
+}
+
+// decodeSecret decodes a base32 seed, ignoring case, spaces and missing padding.
+func decodeSecret(s string) ([]byte, error) {
+	s = strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(s))
+	s = strings.TrimRight(s, "=")
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(s)
+	if err != nil || len(secret) == 0 {
+		return nil, fmt.Errorf("TOTP seed is not valid base32")
+	}
+	return secret, nil
+}
+
+// Code returns the code valid at t.
+func (k *Key) Code(t time.Time) string {
+	var newHash func() hash.Hash
+	switch k.Algorithm {
+	case "SHA256":
+		newHash = sha256.New
+	case "SHA512":
+		newHash = sha512.New
+	default:
+		newHash = sha1.New
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(t.Unix()/int64(k.Period/time.Second)))
+	mac := hmac.New(newHash, k.Secret)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation (RFC 4226 section 5.3)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < k.Digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", k.Digits, value%mod)
+}
+
+// Expires returns when the code valid at t stops being valid.
+func (k *Key) Expires(t time.Time) time.Time {
+	period := int64(k.Period / time.Second)
+	return time.Unix((t.Unix()/period+1)*period, 0)
+}
+
+// URI returns the key as an otpauth://totp/ URI labelled issuer:account.
+func (k *Key) URI(issuer, account string) string {
+	q := url.Values{}
+	q.Set("secret", base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(k.Secret))
+	q.Set("issuer", issuer)
+	q.Set("algorithm", k.Algorithm)
+	q.Set("digits", strconv.Itoa(k.Digits))
+	q.Set("period", strconv.Itoa(int(k.Period/time.Second)))
+	u := url.URL{Scheme: "otpauth", Host: "totp", Path: "/" + issuer + ":" + account, RawQuery: q.Encode()}
+	return u.String()
+}
@@ -0,0 +1,110 @@
+package transfer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"rdpctl/expand"
+	"rdpctl/inherit"
+	"rdpctl/model"
+)
+
+// DuplicateError reports that the destination vault already has a connection with the same name.
+type DuplicateError struct {
+	Name string
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("a connection named %q already exists in the destination vault", e.Name)
+}
+
+// Copy adds a copy of c, a connection of src, to dst and returns it. The copy gets a fresh ID and no
+// usage history. Defaults, group and template settings are resolved in src and written into the
+// copy, so it connects the same way even though dst has its own defaults and templates. Vault
+// variables the copy references are copied into dst too; where dst already defines one with a
+// different value, the reference is replaced by the value from src.
+func Copy(src, dst *model.Vault, c *model.Connection) (*model.Connection, error) {
+	if err := checkName(dst, c.Name); err != nil {
+		return nil, err
+	}
+
+	copied, _ := inherit.Effective(src, c, nil)
+	copied.ID = uuid.New().String()
+	copied.TemplateID = ""
+	copied.ExtraArgs = append([]string(nil), copied.ExtraArgs...)
+	copied.LastConnectedAt = time.Time{}
+	copied.ConnectCount = 0
+	copied.FrequencyScore = 0
+	if c.ReconnectAttempts != nil {
+		attempts := *c.ReconnectAttempts
+		copied.ReconnectAttempts = &attempts
+	}
+
+	copyVariables(src, dst, &copied)
+
+	now := time.Now()
+	copied.CreatedAt = now
+	copied.UpdatedAt = now
+
+	dst.Connections = append(dst.Connections, copied)
+	return &dst.Connections[len(dst.Connections)-1], nil
+}
+
+// copyVariables makes the ${var:name} references in the expandable fields of c resolve in dst as
+// they do in src. References to variables src does not define are left as they are.
+func copyVariables(src, dst *model.Vault, c *model.Connection) {
+	fields := []*string{&c.Host, &c.Domain, &c.Username, &c.Gateway}
+	for i := range c.ExtraArgs {
+		fields = append(fields, &c.ExtraArgs[i])
+	}
+
+	conflicting := map[string]string{}
+	for _, f := range fields {
+		for _, name := range expand.VarRefs(*f) {
+			value, ok := src.Variables[name]
+			if !ok {
+				continue
+			}
+			existing, defined := dst.Variables[name]
+			switch {
+			case !defined:
+				if dst.Variables == nil {
+					dst.Variables = map[string]string{}
+				}
+				dst.Variables[name] = value
+			case existing != value:
+				conflicting[name] = value
+			}
+		}
+	}
+
+	if len(conflicting) == 0 {
+		return
+	}
+	for _, f := range fields {
+		*f = expand.ReplaceVars(*f, conflicting)
+	}
+}
+
+// Add appends c, a connection read from outside rdpctl, to dst unless dst already has a
+// connection with the same name, and returns it.
+func Add(dst *model.Vault, c model.Connection) (*model.Connection, error) {
+	if err := checkName(dst, c.Name); err != nil {
+		return nil, err
+	}
+	dst.Connections = append(dst.Connections, c)
+	return &dst.Connections[len(dst.Connections)-1], nil
+}
+
+// checkName returns a DuplicateError if v has a connection named name (case-insensitive).
+func checkName(v *model.Vault, name string) error {
+	for i := range v.Connections {
+		if strings.EqualFold(v.Connections[i].Name, name) {
+			return &DuplicateError{Name: name}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package trash
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"rdpctl/history"
+	"rdpctl/model"
+)
+
+// Retention is how long deleted connections stay in the trash before Purge removes them for good.
+// 0 keeps them until the trash is emptied.
+var Retention = 30 * 24 * time.Hour
+
+// Move deletes the connection with the given ID by moving it into the vault's trash, taking it out
+// of its workspaces. It returns the new trash entry.
+func Move(v *model.Vault, id string, now time.Time) (*model.TrashedConnection, error) {
+	for i := range v.Connections {
+		if v.Connections[i].ID != id {
+			continue
+		}
+		item := model.TrashedConnection{
+			Connection:  v.Connections[i],
+			DeletedAt:   now,
+			Memberships: removeFromWorkspaces(v, id),
+		}
+		v.Connections = append(v.Connections[:i], v.Connections[i+1:]...)
+		v.Trash = append(v.Trash, item)
+		return &v.Trash[len(v.Trash)-1], nil
+	}
+	return nil, fmt.Errorf("failed to find connection with ID %s to delete", id)
+}
+
+// Find returns the index in v.Trash of the most recently deleted connection named name
+// (case-insensitive).
+func Find(v *model.Vault, name string) (int, error) {
+	found := -1
+	for i := range v.Trash {
+		if strings.EqualFold(v.Trash[i].Connection.Name, name) &&
+			(found < 0 || v.Trash[i].DeletedAt.After(v.Trash[found].DeletedAt)) {
+			found = i
+		}
+	}
+	if found < 0 {
+		return 0, fmt.Errorf("no connection named %q in the trash", name)
+	}
+	return found, nil
+}
+
+// Restore moves the trashed connection at index i back into the vault and into the workspaces it
+// was removed from that still exist. It fails if another connection has taken its name.
+func Restore(v *model.Vault, i int) (*model.Connection, error) {
+	item := v.Trash[i]
+	for _, c := range v.Connections {
+		if strings.EqualFold(c.Name, item.Connection.Name) {
+			return nil, fmt.Errorf("a connection named %q already exists; rename it before restoring", c.Name)
+		}
+	}
+
+	for _, m := range item.Memberships {
+		for w := range v.Workspaces {
+			if v.Workspaces[w].ID == m.WorkspaceID {
+				v.Workspaces[w].Members = append(v.Workspaces[w].Members, m.Member)
+			}
+		}
+	}
+	v.Trash = append(v.Trash[:i], v.Trash[i+1:]...)
+	v.Connections = append(v.Connections, item.Connection)
+	return &v.Connections[len(v.Connections)-1], nil
+}
+
+// Empty permanently removes everything in the trash and returns the removed connections.
+func Empty(v *model.Vault) []model.Connection {
+	return remove(v, func(model.TrashedConnection) bool { return true })
+}
+
+// Purge permanently removes connections that have been in the trash longer than Retention and
+// returns them.
+func Purge(v *model.Vault, now time.Time) []model.Connection {
+	if Retention <= 0 {
+		return nil
+	}
+	return remove(v, func(item model.TrashedConnection) bool {
+		return now.Sub(item.DeletedAt) > Retention
+	})
+}
+
+// Expires returns when Purge will remove item, or the zero time if it is kept until the trash is emptied.
+func Expires(item model.TrashedConnection) time.Time {
+	if Retention <= 0 {
+		return time.Time{}
+	}
+	return item.DeletedAt.Add(Retention)
+}
+
+// remove drops the trashed connections matching drop, together with their history.
+func remove(v *model.Vault, drop func(model.TrashedConnection) bool) []model.Connection {
+	var removed []model.Connection
+	kept := v.Trash[:0]
+	for _, item := range v.Trash {
+		if drop(item) {
+			removed = append(removed, item.Connection)
+			history.Remove(v, item.Connection.ID)
+			continue
+		}
+		kept = append(kept, item)
+	}
+	v.Trash = kept
+	if len(v.Trash) == 0 {
+		v.Trash = nil
+	}
+	return removed
+}
+
+// removeFromWorkspaces drops every workspace member that references the given connection ID and
+// returns the dropped memberships.
+func removeFromWorkspaces(v *model.Vault, connectionID string) []model.TrashedMembership {
+	var removed []model.TrashedMembership
+	for w := range v.Workspaces {
+		members := v.Workspaces[w].Members[:0]
+		for _, m := range v.Workspaces[w].Members {
+			if m.ConnectionID != connectionID {
+				members = append(members, m)
+				continue
+			}
+			removed = append(removed, model.TrashedMembership{WorkspaceID: v.Workspaces[w].ID, Member: m})
+		}
+		v.Workspaces[w].Members = members
+	}
+	return removed
+}
@@ -2,13 +2,18 @@ package ui
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/manifoldco/promptui"
 
+	"rdpctl/inherit"
 	"rdpctl/model"
+	"rdpctl/rdp"
+	"rdpctl/secrets"
+	"rdpctl/wol"
 )
 
 // AddConnection guides the user through adding a new RDP connection profile to the vault.
@@ -43,9 +48,39 @@ func AddConnection(v *model.Vault) error {
 	}
 	newConn.Host = host
 
+	// Prompt for Group (optional)
+	groupPrompt := promptui.Prompt{
+		Label: "Group/customer (optional)",
+	}
+	group, err := groupPrompt.Run()
+	if err != nil {
+		return fmt.Errorf("prompt failed: %w", err)
+	}
+	newConn.Group = strings.TrimSpace(group)
+
+	// Prompt for Tags (optional, comma-separated)
+	tagsPrompt := promptui.Prompt{
+		Label: "Tags (comma-separated, optional)",
+	}
+	tags, err := tagsPrompt.Run()
+	if err != nil {
+		return fmt.Errorf("prompt failed: %w", err)
+	}
+	newConn.Tags = splitArgs(tags)
+
+	newConn.Notes, err = promptNotes("")
+	if err != nil {
+		return err
+	}
+
+	if err := promptTemplate(v, &newConn); err != nil {
+		return err
+	}
+	inherited := inherit.Inherited(v, &newConn)
+
 	// Prompt for Domain (optional)
 	domainPrompt := promptui.Prompt{
-		Label: "Domain (optional)",
+		Label: "Domain (optional" + inheritHint(inherited.Domain) + ")",
 	}
 	domain, err := domainPrompt.Run()
 	if err != nil {
@@ -53,10 +88,10 @@ func AddConnection(v *model.Vault) error {
 	}
 	newConn.Domain = domain
 
-	// Prompt for Username
+	// Prompt for Username (optional only when a default supplies one)
 	usernamePrompt := promptui.Prompt{
-		Label:    "Username",
-		Validate: requireInput,
+		Label:    "Username" + parenthesize(inheritHint(inherited.Username)),
+		Validate: requireUnlessInherited(inherited.Username),
 	}
 	username, err := usernamePrompt.Run()
 	if err != nil {
@@ -64,18 +99,44 @@ func AddConnection(v *model.Vault) error {
 	}
 	newConn.Username = username
 
+	// Prompt for Gateway (optional)
+	gatewayPrompt := promptui.Prompt{
+		Label: "RD Gateway (optional" + inheritHint(inherited.Gateway) + ")",
+	}
+	gateway, err := gatewayPrompt.Run()
+	if err != nil {
+		return fmt.Errorf("prompt failed: %w", err)
+	}
+	newConn.Gateway = strings.TrimSpace(gateway)
+
+	// Prompt for TOTP seed (optional)
+	newConn.TOTP, err = promptTOTP("")
+	if err != nil {
+		return err
+	}
+
 	// Prompt to store password
 	storePassPrompt := promptui.Select{ 
 		Label: "Store password in vault?",
-		Items: []string{"Yes", "No"},
+		Items: []string{"Yes", passwordGenerate, "No", passwordFromStore},
 	}
 	_, storePassResult, err := storePassPrompt.Run()
 	if err != nil {
 		return fmt.Errorf("prompt failed: %w", err)
 	}
-	newConn.StorePassword = (storePassResult == "Yes")
+	newConn.StorePassword = (storePassResult == "Yes" || storePassResult == passwordGenerate)
 
-	if newConn.StorePassword {
+	if storePassResult == passwordFromStore {
+		newConn.PasswordRef, err = promptPasswordRef("")
+		if err != nil {
+			return err
+		}
+	} else if storePassResult == passwordGenerate {
+		newConn.Password, err = promptGeneratedPassword()
+		if err != nil {
+			return err
+		}
+	} else if newConn.StorePassword {
 		passwordPrompt := promptui.Prompt{
 			Label:    "Password",
 			Mask:     '*',
@@ -87,10 +148,13 @@ func AddConnection(v *model.Vault) error {
 		}
 		newConn.Password = password
 	}
+	if newConn.Password != "" {
+		newConn.PasswordChangedAt = time.Now()
+	}
 
 	// Prompt for Extra Args (optional, comma-separated)
 	extraArgsPrompt := promptui.Prompt{
-		Label: "Extra xfreerdp arguments (comma-separated, e.g., /cert-ignore)",
+		Label: "Extra xfreerdp arguments (comma-separated, e.g., /cert-ignore" + inheritHint(strings.Join(inherited.ExtraArgs, " ")) + ")",
 	}
 	extraArgsInput, err := extraArgsPrompt.Run()
 	if err != nil {
@@ -103,6 +167,14 @@ func AddConnection(v *model.Vault) error {
 		}
 	}
 
+	if err := promptWakeSettings(&newConn); err != nil {
+		return err
+	}
+
+	if err := promptReconnectAttempts(&newConn); err != nil {
+		return err
+	}
+
 	v.Connections = append(v.Connections, newConn)
 	fmt.Printf("Connection '%s' added successfully!\n", newConn.Name)
 	return nil
@@ -116,7 +188,11 @@ func EditConnection(v *model.Vault) error {
 	if err != nil {
 		return fmt.Errorf("error selecting connection: %w", err)
 	}
+	return editHost(v, selectedConn)
+}
 
+// editHost prompts for new settings of selectedConn and replaces it in the vault.
+func editHost(v *model.Vault, selectedConn *model.Connection) error {
 	originalID := selectedConn.ID
 	originalCreatedAt := selectedConn.CreatedAt
 
@@ -124,6 +200,61 @@ func EditConnection(v *model.Vault) error {
 	editedConn := *selectedConn
 	editedConn.UpdatedAt = time.Now()
 
+	if err := promptConnection(v, &editedConn); err != nil {
+		return err
+	}
+
+	// Find and replace the old connection with the edited one
+	for i, conn := range v.Connections {
+		if conn.ID == originalID {
+			editedConn.ID = originalID // Ensure ID and creation date are preserved
+			editedConn.CreatedAt = originalCreatedAt
+			v.Connections[i] = editedConn
+			fmt.Printf("Connection '%s' updated successfully!\n", editedConn.Name)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("failed to find connection with ID %s to update", originalID)
+}
+
+// DuplicateConnection guides the user through adding a new RDP connection profile pre-filled from an
+// existing one. The copy gets a fresh ID and starts without usage statistics.
+func DuplicateConnection(v *model.Vault) error {
+	fmt.Println("\n--- Duplicate Host ---")
+
+	source, err := SelectConnection(v)
+	if err != nil {
+		return fmt.Errorf("error selecting connection: %w", err)
+	}
+	return duplicateHost(v, source)
+}
+
+// duplicateHost runs the add flow pre-filled from source and adds the new connection to the vault.
+func duplicateHost(v *model.Vault, source *model.Connection) error {
+	newConn := *source
+	newConn.ID = uuid.New().String()
+	newConn.Name = source.Name + " (copy)"
+	newConn.Tags = append([]string(nil), source.Tags...)
+	newConn.ExtraArgs = append([]string(nil), source.ExtraArgs...)
+	newConn.LastConnectedAt = time.Time{}
+	newConn.ConnectCount = 0
+	newConn.FrequencyScore = 0
+	newConn.CreatedAt = time.Now()
+	newConn.UpdatedAt = time.Now()
+	fmt.Printf("Pre-filled from '%s'. Change what differs for the new host.\n", source.Name)
+
+	if err := promptConnection(v, &newConn); err != nil {
+		return err
+	}
+
+	v.Connections = append(v.Connections, newConn)
+	fmt.Printf("Connection '%s' added successfully!\n", newConn.Name)
+	return nil
+}
+
+// promptConnection prompts for every setting of editedConn, offering its current values as defaults.
+func promptConnection(v *model.Vault, editedConn *model.Connection) error {
 	// Prompt for Friendly Name
 	namePrompt := promptui.Prompt{
 		Label:     fmt.Sprintf("Friendly Name (current: %s)", editedConn.Name),
@@ -148,9 +279,42 @@ func EditConnection(v *model.Vault) error {
 	}
 	editedConn.Host = host
 
+	// Prompt for Group (optional)
+	groupPrompt := promptui.Prompt{
+		Label:   fmt.Sprintf("Group/customer (current: %s)", editedConn.Group),
+		Default: editedConn.Group,
+	}
+	group, err := groupPrompt.Run()
+	if err != nil {
+		return fmt.Errorf("prompt failed: %w", err)
+	}
+	editedConn.Group = strings.TrimSpace(group)
+
+	// Prompt for Tags (optional, comma-separated)
+	tagsDefault := strings.Join(editedConn.Tags, ", ")
+	tagsPrompt := promptui.Prompt{
+		Label:   fmt.Sprintf("Tags (comma-separated, current: %s)", tagsDefault),
+		Default: tagsDefault,
+	}
+	tags, err := tagsPrompt.Run()
+	if err != nil {
+		return fmt.Errorf("prompt failed: %w", err)
+	}
+	editedConn.Tags = splitArgs(tags)
+
+	editedConn.Notes, err = promptNotes(editedConn.Notes)
+	if err != nil {
+		return err
+	}
+
+	if err := promptTemplate(v, editedConn); err != nil {
+		return err
+	}
+	inherited := inherit.Inherited(v, editedConn)
+
 	// Prompt for Domain (optional)
 	domainPrompt := promptui.Prompt{
-		Label:   fmt.Sprintf("Domain (current: %s)", editedConn.Domain),
+		Label:   fmt.Sprintf("Domain (current: %s%s)", editedConn.Domain, inheritHint(inherited.Domain)),
 		Default: editedConn.Domain,
 	}
 	domain, err := domainPrompt.Run()
@@ -161,9 +325,9 @@ func EditConnection(v *model.Vault) error {
 
 	// Prompt for Username
 	usernamePrompt := promptui.Prompt{
-		Label:     fmt.Sprintf("Username (current: %s)", editedConn.Username),
+		Label:     fmt.Sprintf("Username (current: %s%s)", editedConn.Username, inheritHint(inherited.Username)),
 		Default:   editedConn.Username,
-		Validate:  requireInput,
+		Validate:  requireUnlessInherited(inherited.Username),
 	}
 	username, err := usernamePrompt.Run()
 	if err != nil {
@@ -171,26 +335,62 @@ func EditConnection(v *model.Vault) error {
 	}
 	editedConn.Username = username
 
+	// Prompt for Gateway (optional)
+	gatewayPrompt := promptui.Prompt{
+		Label:   fmt.Sprintf("RD Gateway (current: %s%s)", editedConn.Gateway, inheritHint(inherited.Gateway)),
+		Default: editedConn.Gateway,
+	}
+	gateway, err := gatewayPrompt.Run()
+	if err != nil {
+		return fmt.Errorf("prompt failed: %w", err)
+	}
+	editedConn.Gateway = strings.TrimSpace(gateway)
+
+	// Prompt for TOTP seed (optional)
+	editedConn.TOTP, err = promptTOTP(editedConn.TOTP)
+	if err != nil {
+		return err
+	}
+
 	// Prompt to store password
 	var storePassDefault string
-	if editedConn.StorePassword {
+	if editedConn.PasswordRef != "" {
+		storePassDefault = editedConn.PasswordRef
+	} else if editedConn.StorePassword {
 		storePassDefault = "Yes"
 	} else {
 		storePassDefault = "No"
 	}
 	storePassPrompt := promptui.Select{
 		Label:   fmt.Sprintf("Store password in vault? (current: %s)", storePassDefault),
-		Items:   []string{"Yes", "No"},
+		Items:   []string{"Yes", passwordGenerate, "No", passwordFromStore},
 	}
 	_, storePassResult, err := storePassPrompt.Run()
 	if err != nil {
 		return fmt.Errorf("prompt failed: %w", err)
 	}
-	editedConn.StorePassword = (storePassResult == "Yes")
+	editedConn.StorePassword = (storePassResult == "Yes" || storePassResult == passwordGenerate)
+
+	// A secret store reference replaces any stored password
+	if storePassResult == passwordFromStore {
+		editedConn.PasswordRef, err = promptPasswordRef(editedConn.PasswordRef)
+		if err != nil {
+			return err
+		}
+	} else {
+		editedConn.PasswordRef = ""
+	}
 
 	// If not storing, clear password. If storing, prompt for it.
 	if !editedConn.StorePassword {
 		editedConn.Password = "" // Clear stored password if user opts out
+		editedConn.PasswordChangedAt = time.Time{}
+	} else if storePassResult == passwordGenerate {
+		editedConn.Password, err = promptGeneratedPassword()
+		if err != nil {
+			return err
+		}
+		editedConn.PasswordChangedAt = time.Now()
 	} else {
 		passwordPrompt := promptui.Prompt{
 			Label:    "Password (leave blank to keep current or if not stored)",
@@ -200,15 +400,16 @@ func EditConnection(v *model.Vault) error {
 		if err != nil {
 			return fmt.Errorf("prompt failed: %w", err)
 		}
-		if password != "" {
+		if password != "" && password != editedConn.Password {
 			editedConn.Password = password
+			editedConn.PasswordChangedAt = time.Now()
 		}
 	}
 
 	// Prompt for Extra Args (optional, comma-separated)
 	extraArgsDefault := strings.Join(editedConn.ExtraArgs, ", ")
 	extraArgsPrompt := promptui.Prompt{
-		Label:   fmt.Sprintf("Extra xfreerdp arguments (comma-separated, current: %s)", extraArgsDefault),
+		Label:   fmt.Sprintf("Extra xfreerdp arguments (comma-separated, current: %s%s)", extraArgsDefault, inheritHint(strings.Join(inherited.ExtraArgs, " "))),
 		Default: extraArgsDefault,
 	}
 	extraArgsInput, err := extraArgsPrompt.Run()
@@ -224,18 +425,168 @@ func EditConnection(v *model.Vault) error {
 		editedConn.ExtraArgs = []string{} // Clear extra args if input is empty
 	}
 
-	// Find and replace the old connection with the edited one
-	for i, conn := range v.Connections {
-		if conn.ID == originalID {
-			editedConn.ID = originalID // Ensure ID and creation date are preserved
-			editedConn.CreatedAt = originalCreatedAt
-			v.Connections[i] = editedConn
-			fmt.Printf("Connection '%s' updated successfully!\n", editedConn.Name)
+	if err := promptWakeSettings(editedConn); err != nil {
+		return err
+	}
+
+	if err := promptReconnectAttempts(editedConn); err != nil {
+		return err
+	}
+	return nil
+}
+
+// promptNotes prompts for the optional notes of a connection and returns them. Notes spanning
+// several lines, as imported from a password manager, cannot be edited in a single-line prompt,
+// so they are kept unless replaced, or cleared with "-".
+func promptNotes(current string) (string, error) {
+	prompt := promptui.Prompt{
+		Label:   fmt.Sprintf("Notes (current: %s)", current),
+		Default: current,
+	}
+	multiLine := strings.Contains(current, "\n")
+	if current == "" {
+		prompt.Label = "Notes (optional)"
+	} else if multiLine {
+		first, _, _ := strings.Cut(current, "\n")
+		prompt.Label = fmt.Sprintf("Notes (current: %s ..., %d lines; blank keeps them, - clears)", first, strings.Count(current, "\n")+1)
+		prompt.Default = ""
+	}
+
+	notes, err := prompt.Run()
+	if err != nil {
+		return "", fmt.Errorf("prompt failed: %w", err)
+	}
+	notes = strings.TrimSpace(notes)
+	switch {
+	case multiLine && notes == "":
+		return current, nil
+	case multiLine && notes == "-":
+		return "", nil
+	}
+	return notes, nil
+}
+
+// promptWakeSettings prompts for the optional Wake-on-LAN settings of a connection,
+// using the connection's current values as defaults.
+func promptWakeSettings(c *model.Connection) error {
+	macPrompt := promptui.Prompt{
+		Label:   "MAC address for Wake-on-LAN (optional)",
+		Default: c.MACAddress,
+		Validate: func(input string) error {
+			if strings.TrimSpace(input) == "" {
+				return nil
+			}
+			_, err := wol.MagicPacket(strings.TrimSpace(input))
+			return err
+		},
+	}
+	mac, err := macPrompt.Run()
+	if err != nil {
+		return fmt.Errorf("prompt failed: %w", err)
+	}
+	c.MACAddress = strings.TrimSpace(mac)
+
+	if c.MACAddress == "" {
+		c.WakeBroadcast = ""
+		c.WakePort = 0
+		c.WakeOnConnect = false
+		return nil
+	}
+
+	broadcastPrompt := promptui.Prompt{
+		Label:   fmt.Sprintf("Broadcast address (blank for %s)", wol.DefaultBroadcast),
+		Default: c.WakeBroadcast,
+	}
+	broadcast, err := broadcastPrompt.Run()
+	if err != nil {
+		return fmt.Errorf("prompt failed: %w", err)
+	}
+	c.WakeBroadcast = strings.TrimSpace(broadcast)
+
+	portDefault := ""
+	if c.WakePort != 0 {
+		portDefault = strconv.Itoa(c.WakePort)
+	}
+	portPrompt := promptui.Prompt{
+		Label:    fmt.Sprintf("Wake-on-LAN UDP port (blank for %d)", wol.DefaultPort),
+		Default:  portDefault,
+		Validate: optionalPort,
+	}
+	portInput, err := portPrompt.Run()
+	if err != nil {
+		return fmt.Errorf("prompt failed: %w", err)
+	}
+	c.WakePort = 0
+	if strings.TrimSpace(portInput) != "" {
+		c.WakePort, _ = strconv.Atoi(strings.TrimSpace(portInput))
+	}
+
+	wakePrompt := promptui.Select{
+		Label: "Wake host and wait for RDP before connecting?",
+		Items: []string{"Yes", "No"},
+	}
+	_, wakeResult, err := wakePrompt.Run()
+	if err != nil {
+		return fmt.Errorf("prompt failed: %w", err)
+	}
+	c.WakeOnConnect = (wakeResult == "Yes")
+
+	return nil
+}
+
+// promptReconnectAttempts prompts for the connection's reconnect limit. A blank answer
+// keeps the global default.
+func promptReconnectAttempts(c *model.Connection) error {
+	attemptsDefault := ""
+	if c.ReconnectAttempts != nil {
+		attemptsDefault = strconv.Itoa(*c.ReconnectAttempts)
+	}
+	attemptsPrompt := promptui.Prompt{
+		Label:   fmt.Sprintf("Reconnect attempts after network drops (blank for default %d, 0 to disable)", rdp.PolicyFor(&model.Connection{}).MaxAttempts),
+		Default: attemptsDefault,
+		Validate: func(input string) error {
+			if strings.TrimSpace(input) == "" {
+				return nil
+			}
+			if n, err := strconv.Atoi(strings.TrimSpace(input)); err != nil || n < 0 {
+				return fmt.Errorf("enter a non-negative number")
+			}
 			return nil
-		}
+		},
+	}
+	attemptsInput, err := attemptsPrompt.Run()
+	if err != nil {
+		return fmt.Errorf("prompt failed: %w", err)
 	}
 
-	return fmt.Errorf("failed to find connection with ID %s to update", originalID)
+	c.ReconnectAttempts = nil
+	if strings.TrimSpace(attemptsInput) != "" {
+		n, _ := strconv.Atoi(strings.TrimSpace(attemptsInput))
+		c.ReconnectAttempts = &n
+	}
+	return nil
+}
+
+// optionalPort is a promptui.ValidateFunc accepting an empty string or a valid port number.
+func optionalPort(input string) error {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return nil
+	}
+	port, err := strconv.Atoi(input)
+	if err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("port must be a number between 1 and 65535")
+	}
+	return nil
+}
+
+// requireUnlessInherited returns a promptui.ValidateFunc that requires input only when
+// there is no inherited value to fall back to.
+func requireUnlessInherited(inherited string) promptui.ValidateFunc {
+	if inherited != "" {
+		return nil
+	}
+	return requireInput
 }
 
 // requireInput is a promptui.ValidateFunc to ensure the input is not empty.
@@ -245,3 +596,23 @@ func requireInput(input string) error {
 	}
 	return nil
 }
+
+// passwordFromStore is the password storage choice for a secret store reference.
+const passwordFromStore = "No, fetch it from a secret store"
+
+// promptPasswordRef asks for the secret store reference of a connection's password.
+func promptPasswordRef(current string) (string, error) {
+	refPrompt := promptui.Prompt{
+		Label:   "Secret reference (" + strings.Join(secrets.Schemes(), "://, ") + "://)",
+		Default: current,
+		Validate: func(input string) error {
+			_, err := secrets.Parse(input)
+			return err
+		},
+	}
+	ref, err := refPrompt.Run()
+	if err != nil {
+		return "", fmt.Errorf("prompt failed: %w", err)
+	}
+	return strings.TrimSpace(ref), nil
+}
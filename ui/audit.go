@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"rdpctl/audit"
+	"rdpctl/history"
+	"rdpctl/model"
+	"rdpctl/rdp"
+	"rdpctl/session"
+	"rdpctl/timesheet"
+	"rdpctl/usage"
+	"rdpctl/vault"
+)
+
+// recordAudit appends events to the vault's audit log. Audit failures are reported but never
+// block the operation being audited. The caller must save the vault to anchor the new entries.
+func recordAudit(vaultPath string, v *model.Vault, events ...audit.Event) {
+	path := audit.PathFor(vaultPath)
+	for _, e := range events {
+		if err := audit.Record(path, v, e); err != nil {
+			fmt.Printf("Warning: failed to write audit log: %v\n", err)
+			return
+		}
+	}
+}
+
+// saveChanges records add, edit and delete events and the connection history for the differences
+// between before and the vault's current connections, then saves the vault.
+func saveChanges(v *model.Vault, before []model.Connection, masterPassword string, vaultPath string) error {
+	history.Record(v, before, time.Now())
+	recordAudit(vaultPath, v, audit.ChangeEvents(before, v.Connections)...)
+	return vault.SaveVault(vaultPath, v, masterPassword)
+}
+
+// snapshotConnections returns a copy of the vault's connections for a later saveChanges.
+func snapshotConnections(v *model.Vault) []model.Connection {
+	return append([]model.Connection(nil), v.Connections...)
+}
+
+// RecordLaunch notes that c was launched: it updates the connection's usage statistics, starts a
+// timesheet entry, and for background launches (sess is non-nil), which will not report back,
+// writes the connect audit event. It returns the timesheet entry's ID. The caller must save the vault.
+func RecordLaunch(vaultPath string, v *model.Vault, c *model.Connection, sess *session.Session) string {
+	now := time.Now()
+	usage.Record(c, now)
+
+	if sess == nil {
+		// A foreground session ends with this process
+		self, _ := session.Identify(os.Getpid())
+		return timesheet.Start(v, c, now, os.Getpid(), self.StartTime, "")
+	}
+
+	e := audit.NewEvent(audit.EventConnect).ForConnection(c)
+	e.Launcher = rdp.Launcher + " (background)"
+	recordAudit(vaultPath, v, e)
+	return timesheet.Start(v, c, now, sess.PID, sess.StartTime, sess.LogFile)
+}
+
+// recordConnectEnd closes the timesheet entry and writes the audit event for a foreground session
+// that has ended.
+func recordConnectEnd(vaultPath string, v *model.Vault, c *model.Connection, sessionID string, started time.Time, connectErr error) {
+	timesheet.Finish(v, sessionID, time.Now())
+
+	e := audit.NewEvent(audit.EventConnect).ForConnection(c)
+	e.Launcher = rdp.Launcher
+	e.DurationSeconds = time.Since(started).Round(time.Second).Seconds()
+
+	status := 0
+	var clientErr *rdp.ClientError
+	if errors.As(connectErr, &clientErr) {
+		status = clientErr.ExitCode
+	}
+	if connectErr == nil || clientErr != nil {
+		e.ExitStatus = &status
+	}
+	if connectErr != nil {
+		e.Detail = connectErr.Error()
+	}
+
+	recordAudit(vaultPath, v, e)
+}
@@ -0,0 +1,341 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/manifoldco/promptui"
+
+	"rdpctl/audit"
+	"rdpctl/model"
+)
+
+// Ways of choosing the hosts of a bulk edit.
+const (
+	bulkBySearch = "Search by name or host"
+	bulkByGroup  = "By group"
+	bulkByTag    = "By tag"
+)
+
+// Fields a bulk edit can change.
+const (
+	bulkUsername   = "Username"
+	bulkDomain     = "Domain"
+	bulkCredential = "Credential"
+	bulkExtraArgs  = "Extra arguments"
+	bulkGateway    = "RD Gateway"
+)
+
+// Entries of the host chooser above the list of hosts.
+const (
+	chooserContinue   = 0
+	chooserSelectAll  = 1
+	chooserSelectNone = 2
+	chooserFirstEntry = 3
+)
+
+// BulkEdit lets the user pick several hosts by search, group or tag and change one field on all of
+// them. The changes are previewed and applied together only after confirmation; the caller saves
+// them as one vault write.
+func BulkEdit(v *model.Vault) error {
+	fmt.Println("\n--- Bulk Edit Hosts ---")
+
+	candidates, err := bulkCandidates(v)
+	if err != nil {
+		return err
+	}
+	if len(candidates) == 0 {
+		fmt.Println("No hosts match.")
+		return nil
+	}
+
+	chosen, err := chooseHosts(candidates)
+	if err != nil || len(chosen) == 0 {
+		return err
+	}
+
+	fieldPrompt := promptui.Select{
+		Label: fmt.Sprintf("Field to change on %d hosts", len(chosen)),
+		Items: []string{bulkUsername, bulkDomain, bulkCredential, bulkExtraArgs, bulkGateway},
+	}
+	_, field, err := fieldPrompt.Run()
+	if err != nil {
+		return fmt.Errorf("prompt failed: %w", err)
+	}
+	apply, err := promptBulkValue(field)
+	if err != nil {
+		return err
+	}
+
+	// Apply the change to copies first, so nothing changes unless the preview is confirmed
+	now := time.Now()
+	edited := make([]model.Connection, len(chosen))
+	changed := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tFIELD\tOLD\tNEW")
+	for i, c := range chosen {
+		edited[i] = *c
+		apply(&edited[i], now)
+		changes := audit.Diff(c, &edited[i])
+		if len(changes) > 0 {
+			changed++
+		}
+		for _, change := range changes {
+			if change.Field == "passwordChangedAt" {
+				continue
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Name, change.Field, change.Old, change.New)
+		}
+	}
+	w.Flush()
+
+	if changed == 0 {
+		fmt.Println("Nothing to change.")
+		return nil
+	}
+	confirmPrompt := promptui.Prompt{
+		Label:     fmt.Sprintf("Apply these changes to %d hosts? (yes/no)", changed),
+		IsConfirm: true,
+	}
+	if _, err := confirmPrompt.Run(); err != nil {
+		fmt.Println("Bulk edit cancelled.")
+		return nil
+	}
+
+	for i, c := range chosen {
+		if len(audit.Diff(c, &edited[i])) > 0 {
+			edited[i].UpdatedAt = now
+			*c = edited[i]
+		}
+	}
+	fmt.Printf("%d hosts updated.\n", changed)
+	return nil
+}
+
+// bulkCandidates asks how to find the hosts of a bulk edit and returns the matching connections.
+func bulkCandidates(v *model.Vault) ([]*model.Connection, error) {
+	if len(v.Connections) == 0 {
+		return nil, fmt.Errorf("no connections available. Please add a new host first.")
+	}
+
+	byPrompt := promptui.Select{
+		Label: "Select hosts",
+		Items: []string{bulkBySearch, bulkByGroup, bulkByTag},
+	}
+	_, by, err := byPrompt.Run()
+	if err != nil {
+		return nil, fmt.Errorf("prompt failed: %w", err)
+	}
+
+	var match func(c *model.Connection) bool
+	switch by {
+	case bulkBySearch:
+		searchPrompt := promptui.Prompt{
+			Label: "Name or host contains",
+		}
+		input, err := searchPrompt.Run()
+		if err != nil {
+			return nil, fmt.Errorf("prompt failed: %w", err)
+		}
+		input = strings.ToLower(strings.TrimSpace(input))
+		match = func(c *model.Connection) bool {
+			return strings.Contains(strings.ToLower(c.Name), input) || strings.Contains(strings.ToLower(c.Host), input)
+		}
+	case bulkByGroup:
+		group, err := selectValue("Group", v, func(c *model.Connection) []string { return []string{c.Group} })
+		if err != nil {
+			return nil, err
+		}
+		match = func(c *model.Connection) bool { return c.Group == group }
+	case bulkByTag:
+		tag, err := selectValue("Tag", v, func(c *model.Connection) []string { return c.Tags })
+		if err != nil {
+			return nil, err
+		}
+		match = func(c *model.Connection) bool {
+			for _, t := range c.Tags {
+				if strings.EqualFold(t, tag) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	var matches []*model.Connection
+	for i := range v.Connections {
+		if match(&v.Connections[i]) {
+			matches = append(matches, &v.Connections[i])
+		}
+	}
+	return matches, nil
+}
+
+// selectValue lets the user pick one of the distinct non-empty values that values returns for the
+// vault's connections.
+func selectValue(label string, v *model.Vault, values func(c *model.Connection) []string) (string, error) {
+	seen := map[string]bool{}
+	var items []string
+	for i := range v.Connections {
+		for _, value := range values(&v.Connections[i]) {
+			if value != "" && !seen[strings.ToLower(value)] {
+				seen[strings.ToLower(value)] = true
+				items = append(items, value)
+			}
+		}
+	}
+	if len(items) == 0 {
+		return "", fmt.Errorf("no host has a %s", strings.ToLower(label))
+	}
+	sort.Strings(items)
+
+	prompt := promptui.Select{
+		Label: label,
+		Items: items,
+		Size:  PageSize,
+	}
+	_, value, err := prompt.Run()
+	if err != nil {
+		return "", fmt.Errorf("prompt failed: %w", err)
+	}
+	return value, nil
+}
+
+// chooseHosts lets the user narrow down the hosts of a bulk edit, all of which start out selected.
+func chooseHosts(conns []*model.Connection) ([]*model.Connection, error) {
+	selected := make([]bool, len(conns))
+	for i := range selected {
+		selected[i] = true
+	}
+
+	cursor := chooserContinue
+	for {
+		count := 0
+		for _, s := range selected {
+			if s {
+				count++
+			}
+		}
+
+		items := []string{fmt.Sprintf("Continue with %d selected", count), "Select all", "Select none"}
+		for i, c := range conns {
+			items = append(items, importLabel(c, selected[i], false))
+		}
+
+		prompt := promptui.Select{
+			Label: "Choose hosts to edit (Enter toggles)",
+			Items: items,
+			Size:  PageSize,
+			Searcher: func(input string, index int) bool {
+				return strings.Contains(strings.ToLower(items[index]), strings.ToLower(input))
+			},
+		}
+		i, _, err := prompt.RunCursorAt(cursor, cursor-PageSize+1)
+		if err != nil {
+			return nil, fmt.Errorf("prompt failed: %w", err)
+		}
+		cursor = i
+
+		switch {
+		case i == chooserContinue:
+			var chosen []*model.Connection
+			for j, c := range conns {
+				if selected[j] {
+					chosen = append(chosen, c)
+				}
+			}
+			if len(chosen) == 0 {
+				fmt.Println("Nothing selected.")
+			}
+			return chosen, nil
+		case i == chooserSelectAll || i == chooserSelectNone:
+			for j := range selected {
+				selected[j] = i == chooserSelectAll
+			}
+		default:
+			j := i - chooserFirstEntry
+			selected[j] = !selected[j]
+		}
+	}
+}
+
+// promptBulkValue asks for the new value of field and returns a function that sets it on a connection.
+func promptBulkValue(field string) (func(c *model.Connection, now time.Time), error) {
+	if field == bulkCredential {
+		return promptBulkCredential()
+	}
+
+	label := "New " + strings.ToLower(field) + " (blank inherits or clears it)"
+	if field == bulkExtraArgs {
+		label = "New extra xfreerdp arguments (comma-separated, blank clears them)"
+	}
+	valuePrompt := promptui.Prompt{
+		Label: label,
+	}
+	value, err := valuePrompt.Run()
+	if err != nil {
+		return nil, fmt.Errorf("prompt failed: %w", err)
+	}
+	value = strings.TrimSpace(value)
+
+	return func(c *model.Connection, now time.Time) {
+		switch field {
+		case bulkUsername:
+			c.Username = value
+		case bulkDomain:
+			c.Domain = value
+		case bulkGateway:
+			c.Gateway = value
+		case bulkExtraArgs:
+			c.ExtraArgs = splitArgs(value)
+		}
+	}, nil
+}
+
+// promptBulkCredential asks how the chosen hosts get their password: one password stored in all of
+// them, a secret store reference, or a prompt at connect time.
+func promptBulkCredential() (func(c *model.Connection, now time.Time), error) {
+	storePassPrompt := promptui.Select{
+		Label: "Store password in vault?",
+		Items: []string{"Yes", "No", passwordFromStore},
+	}
+	_, storePassResult, err := storePassPrompt.Run()
+	if err != nil {
+		return nil, fmt.Errorf("prompt failed: %w", err)
+	}
+
+	var password, ref string
+	switch storePassResult {
+	case "Yes":
+		passwordPrompt := promptui.Prompt{
+			Label:    "Password",
+			Mask:     '*',
+			Validate: requireInput,
+		}
+		password, err = passwordPrompt.Run()
+		if err != nil {
+			return nil, fmt.Errorf("prompt failed: %w", err)
+		}
+	case passwordFromStore:
+		ref, err = promptPasswordRef("")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return func(c *model.Connection, now time.Time) {
+		c.StorePassword = password != ""
+		c.PasswordRef = ref
+		if c.Password != password {
+			c.Password = password
+			c.PasswordChangedAt = time.Time{}
+			if password != "" {
+				c.PasswordChangedAt = now
+			}
+		}
+	}, nil
+}
@@ -0,0 +1,166 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/manifoldco/promptui"
+
+	"rdpctl/model"
+	"rdpctl/rdp"
+	"rdpctl/secrets"
+	"rdpctl/session"
+	"rdpctl/wol"
+)
+
+// ConnectToHost prompts for a password if needed, optionally wakes the host, and launches the RDP client.
+// launched, if non-nil, is called right before the client starts, with a nil session.
+func ConnectToHost(v *model.Vault, c *model.Connection, launched func(*session.Session)) error {
+	connPassword, err := prepareConnection(c)
+	if err != nil {
+		return err
+	}
+
+	if launched != nil {
+		launched(nil)
+	}
+
+	fmt.Printf("Connecting to %s...\n", c.Name)
+	return rdp.Run(c, connPassword, v.Variables)
+}
+
+// OfferPasswordUpdate asks whether to replace the stored password of c after the server rejected it,
+// and prompts for the new one. It reports whether c was changed and the vault needs saving.
+func OfferPasswordUpdate(c *model.Connection, connectErr error) (bool, error) {
+	if !errors.Is(connectErr, rdp.ErrLogonFailure) {
+		return false, nil
+	}
+	if c.PasswordRef != "" {
+		fmt.Printf("The password from %s was rejected. Update it in that secret store.\n", c.PasswordRef)
+		return false, nil
+	}
+	if !c.StorePassword {
+		return false, nil
+	}
+
+	confirmPrompt := promptui.Prompt{
+		Label:     fmt.Sprintf("The stored password for '%s' was rejected. Enter a new one now", c.Name),
+		IsConfirm: true,
+	}
+	if _, err := confirmPrompt.Run(); err != nil {
+		return false, nil
+	}
+
+	passwordPrompt := promptui.Prompt{
+		Label:    "New password for " + c.Username + "@" + c.Host,
+		Mask:     '*',
+		Validate: requireInput,
+	}
+	password, err := passwordPrompt.Run()
+	if err != nil {
+		return false, fmt.Errorf("prompt failed: %w", err)
+	}
+
+	c.Password = password
+	c.PasswordChangedAt = time.Now()
+	c.UpdatedAt = c.PasswordChangedAt
+	return true, nil
+}
+
+// ConnectInBackground prepares the connection like ConnectToHost but launches the RDP client
+// detached from the terminal and records it as a running session. launched, if non-nil,
+// is called with the new session once the client has started.
+func ConnectInBackground(v *model.Vault, c *model.Connection, launched func(*session.Session)) error {
+	connPassword, err := prepareConnection(c)
+	if err != nil {
+		return err
+	}
+
+	store, err := session.DefaultStore()
+	if err != nil {
+		return err
+	}
+
+	sess, err := store.Launch(c, connPassword, v.Variables)
+	if err != nil {
+		return err
+	}
+	if launched != nil {
+		launched(sess)
+	}
+
+	fmt.Printf("Started %s in the background (PID %d, log: %s)\n", c.Name, sess.PID, sess.LogFile)
+	return nil
+}
+
+// prepareConnection returns the password to use for c, prompting when it is not stored,
+// wakes the host first when the connection asks for it, and shows its TOTP code.
+func prepareConnection(c *model.Connection) (string, error) {
+	connPassword, err := connectionPassword(c)
+	if err != nil {
+		return "", err
+	}
+
+	if err := wakeIfConfigured(c); err != nil {
+		return "", err
+	}
+
+	// Shown last, after any wait for the host, so the code is fresh when the gateway asks for it
+	if err := ShowTOTP(c); err != nil {
+		return "", err
+	}
+
+	return connPassword, nil
+}
+
+// connectionPassword returns the password for c: fetched from its secret store reference, stored
+// in the vault, or prompted for.
+func connectionPassword(c *model.Connection) (string, error) {
+	if c.PasswordRef != "" {
+		fmt.Printf("Fetching password for %s from %s...\n", c.Name, c.PasswordRef)
+		return secrets.Resolve(context.Background(), c.PasswordRef)
+	}
+	if c.StorePassword && c.Password != "" {
+		return c.Password, nil
+	}
+
+	passwordPrompt := promptui.Prompt{
+		Label: "Enter password for " + c.Username + "@" + c.Host,
+		Mask:  '*',
+	}
+	return passwordPrompt.Run()
+}
+
+// wakeIfConfigured wakes the host and waits for RDP when the connection has wake-on-connect enabled.
+func wakeIfConfigured(c *model.Connection) error {
+	if !c.WakeOnConnect || c.MACAddress == "" {
+		return nil
+	}
+	return WakeAndWait(c, wol.DefaultWaitTimeout)
+}
+
+// WakeAndWait sends a Wake-on-LAN packet to the connection's host and waits for its RDP port to open.
+func WakeAndWait(c *model.Connection, timeout time.Duration) error {
+	if c.MACAddress == "" {
+		return fmt.Errorf("connection '%s' has no MAC address configured", c.Name)
+	}
+
+	fmt.Printf("Sending Wake-on-LAN packet to %s (%s)...\n", c.Name, c.MACAddress)
+	if err := wol.Send(c.MACAddress, c.WakeBroadcast, c.WakePort); err != nil {
+		return fmt.Errorf("wake-on-LAN failed: %w", err)
+	}
+
+	address := rdp.Address(c)
+	fmt.Printf("Waiting up to %s for %s to accept connections...\n", timeout, address)
+	err := wol.WaitForPort(address, timeout, func(elapsed time.Duration) {
+		fmt.Printf("  still waiting (%s elapsed)\n", elapsed.Round(time.Second))
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s is up.\n", c.Name)
+	return nil
+}
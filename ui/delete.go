@@ -2,13 +2,16 @@ package ui
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/manifoldco/promptui"
 
 	"rdpctl/model"
+	"rdpctl/trash"
 )
 
 // DeleteConnection guides the user through deleting an existing RDP connection profile from the vault.
+// The connection is moved to the vault's trash, from which it can be restored until it is purged.
 func DeleteConnection(v *model.Vault) error {
 	fmt.Println("\n--- Delete Host ---")
 
@@ -16,26 +19,26 @@ func DeleteConnection(v *model.Vault) error {
 	if err != nil {
 		return fmt.Errorf("error selecting connection: %w", err)
 	}
+	return deleteHost(v, selectedConn)
+}
 
+// deleteHost asks for confirmation and moves selectedConn to the trash.
+func deleteHost(v *model.Vault, selectedConn *model.Connection) error {
 	confirmPrompt := promptui.Prompt{
 		Label:     fmt.Sprintf("Are you sure you want to delete '%s'? (yes/no)", selectedConn.Name),
 		IsConfirm: true,
 	}
-	_, err = confirmPrompt.Run()
+	_, err := confirmPrompt.Run()
 
 	if err != nil {
 		fmt.Println("Deletion cancelled.")
 		return nil // User cancelled or input was not 'yes'
 	}
 
-	// Find and remove the connection
-	for i, conn := range v.Connections {
-		if conn.ID == selectedConn.ID {
-			v.Connections = append(v.Connections[:i], v.Connections[i+1:]...)
-			fmt.Printf("Connection '%s' deleted successfully!\n", selectedConn.Name)
-			return nil
-		}
+	item, err := trash.Move(v, selectedConn.ID, time.Now())
+	if err != nil {
+		return err
 	}
-
-	return fmt.Errorf("failed to find connection with ID %s to delete", selectedConn.ID)
+	fmt.Printf("Connection '%s' moved to the trash.%s\n", item.Connection.Name, trashExpiry(item))
+	return nil
 }
@@ -0,0 +1,133 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/manifoldco/promptui"
+
+	"rdpctl/model"
+)
+
+// Entries of the import chooser above the list of connections.
+const (
+	importContinue   = 0
+	importSelectAll  = 1
+	importSelectNone = 2
+	importFirstEntry = 3
+)
+
+// ReviewImport lets the user choose which connections read from an export file to import, shows
+// a preview of them and asks for confirmation. It returns the chosen connections, or none if the
+// user cancelled. Connections whose name is already in v are shown but cannot be chosen.
+func ReviewImport(v *model.Vault, conns []model.Connection) ([]model.Connection, error) {
+	fmt.Println("\n--- Import Connections ---")
+
+	exists := map[string]bool{}
+	for _, c := range v.Connections {
+		exists[strings.ToLower(c.Name)] = true
+	}
+	selected := make([]bool, len(conns))
+	for i, c := range conns {
+		selected[i] = !exists[strings.ToLower(c.Name)]
+	}
+
+	cursor := importFirstEntry
+	for {
+		count := 0
+		for _, s := range selected {
+			if s {
+				count++
+			}
+		}
+
+		items := []string{fmt.Sprintf("Continue with %d selected", count), "Select all", "Select none"}
+		for i, c := range conns {
+			items = append(items, importLabel(&c, selected[i], exists[strings.ToLower(c.Name)]))
+		}
+
+		prompt := promptui.Select{
+			Label: "Choose connections to import (Enter toggles)",
+			Items: items,
+			Size:  PageSize,
+			Searcher: func(input string, index int) bool {
+				return strings.Contains(strings.ToLower(items[index]), strings.ToLower(input))
+			},
+		}
+		i, _, err := prompt.RunCursorAt(cursor, cursor-PageSize+1)
+		if err != nil {
+			return nil, fmt.Errorf("prompt failed: %w", err)
+		}
+		cursor = i
+
+		switch {
+		case i == importContinue:
+			var chosen []model.Connection
+			for j, c := range conns {
+				if selected[j] {
+					chosen = append(chosen, c)
+				}
+			}
+			if len(chosen) == 0 {
+				fmt.Println("Nothing selected.")
+				return nil, nil
+			}
+			return confirmImport(chosen), nil
+		case i == importSelectAll || i == importSelectNone:
+			for j, c := range conns {
+				selected[j] = i == importSelectAll && !exists[strings.ToLower(c.Name)]
+			}
+		default:
+			j := i - importFirstEntry
+			if exists[strings.ToLower(conns[j].Name)] {
+				fmt.Printf("'%s' is already in the vault.\n", conns[j].Name)
+				continue
+			}
+			selected[j] = !selected[j]
+		}
+	}
+}
+
+// importLabel renders a connection in the import chooser.
+func importLabel(c *model.Connection, selected, exists bool) string {
+	if exists {
+		return fmt.Sprintf("[-] %s (%s), already in vault", c.Name, c.Host)
+	}
+	mark := "[ ]"
+	if selected {
+		mark = "[x]"
+	}
+	label := fmt.Sprintf("%s %s (%s)", mark, c.Name, c.Host)
+	if c.Group != "" {
+		label += " in " + c.Group
+	}
+	return label
+}
+
+// confirmImport prints the chosen connections and returns them if the user confirms the import.
+func confirmImport(conns []model.Connection) []model.Connection {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tHOST\tUSERNAME\tDOMAIN\tGROUP\tPASSWORD")
+	for _, c := range conns {
+		password := "not stored"
+		if c.PasswordRef != "" {
+			password = c.PasswordRef
+		} else if c.StorePassword {
+			password = "stored"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", c.Name, c.Host, c.Username, c.Domain, c.Group, password)
+	}
+	w.Flush()
+
+	confirmPrompt := promptui.Prompt{
+		Label:     fmt.Sprintf("Import these %d connections? (yes/no)", len(conns)),
+		IsConfirm: true,
+	}
+	if _, err := confirmPrompt.Run(); err != nil {
+		fmt.Println("Import cancelled.")
+		return nil
+	}
+	return conns
+}
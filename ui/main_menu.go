@@ -2,30 +2,98 @@ package ui
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/manifoldco/promptui"
 
+	"rdpctl/config"
+	"rdpctl/inherit"
 	"rdpctl/model"
-	"rdpctl/rdp"
+	"rdpctl/session"
+	"rdpctl/usage"
 	"rdpctl/vault"
 )
 
+// Main menu entries.
+const (
+	menuConnect           = "Connect to a host"
+	menuConnectBackground = "Connect in background"
+	menuConnectOverrides  = "Connect with overrides"
+	menuSessions          = "Running sessions"
+	menuKillSession       = "Kill session"
+	menuWorkspaces        = "Workspaces"
+	menuTemplates         = "Templates & defaults"
+	menuAdd               = "Add new host"
+	menuEdit              = "Edit existing host"
+	menuDuplicate         = "Duplicate host"
+	menuBulkEdit          = "Bulk edit hosts"
+	menuDelete            = "Delete host"
+	menuTrashRestore      = "Restore from trash"
+	menuTrashEmpty        = "Empty trash"
+	menuShow              = "Show vault"
+	menuSortOrder         = "Change sort order"
+	menuSwitchVault       = "Switch vault"
+	menuCopyToVault       = "Copy hosts to another vault"
+	menuQuit              = "Quit"
+)
+
+// Interactive menu settings, overridden from the configuration file.
+var (
+	// RecentCount is the number of recently used hosts shown at the top of the main menu.
+	RecentCount = 5
+	// PageSize is the number of rows shown at once in selection lists.
+	PageSize = 10
+	// IdleLock is how long the menu may sit idle before the master password is asked for again; 0 disables.
+	IdleLock time.Duration
+)
+
 // MainMenu displays the main menu and handles user selections.
 func MainMenu(v *model.Vault, masterPassword string, vaultPath string) error {
 	for {
+		shown := time.Now()
+
+		// Recently used hosts come first so they can be reconnected to with one keystroke
+		items := []string{}
+		recent := map[string]*model.Connection{}
+		for _, c := range usage.Recent(v.Connections, RecentCount) {
+			label := fmt.Sprintf("Recent: %s (%s)", c.Name, c.Host)
+			recent[label] = c
+			items = append(items, label)
+		}
+		items = append(items,
+			menuConnect,
+			menuConnectBackground,
+			menuConnectOverrides,
+			menuSessions,
+			menuKillSession,
+			menuWorkspaces,
+			menuAdd,
+			menuEdit,
+			menuDuplicate,
+			menuBulkEdit,
+			menuDelete,
+			menuTrashRestore,
+			menuTrashEmpty,
+			menuShow,
+			menuTemplates,
+			menuSortOrder,
+			menuSwitchVault,
+			menuCopyToVault,
+			menuQuit,
+		)
+
+		label := "Main Menu"
+		if name := VaultLabel(vaultPath); name != config.DefaultProfile {
+			label = fmt.Sprintf("Main Menu (vault: %s)", name)
+		}
+
 		prompt := promptui.Select{
-			Label: "Main Menu",
-			Items: []string{
-				"Connect to a host",
-				"Add new host",
-				"Edit existing host",
-				"Delete host",
-				"Show vault",
-				"Quit",
-			},
+			Label: label,
+			Items: items,
+			Size:  len(items),
 		}
 
-		i, _, err := prompt.Run()
+		_, choice, err := prompt.Run()
 		if err != nil {
 			// If the user presses Ctrl+C, it's considered an interrupt and we should exit gracefully.
 			if err == promptui.ErrInterrupt {
@@ -36,8 +104,20 @@ func MainMenu(v *model.Vault, masterPassword string, vaultPath string) error {
 			return err
 		}
 
-		switch i {
-			case 0: // Connect to a host
+		// Ask for the master password again if the menu was left open unattended
+		if IdleLock > 0 && time.Since(shown) > IdleLock {
+			if err := relock(vaultPath, masterPassword); err != nil {
+				return err
+			}
+		}
+
+		if c, ok := recent[choice]; ok {
+			ConnectAndSave(v, c, false, nil, masterPassword, vaultPath)
+			continue
+		}
+
+		switch choice {
+			case menuConnect, menuConnectBackground:
 				selectedConn, err := SelectConnection(v)
 				if err != nil {
 					// If the user cancelled the selection, continue to main menu
@@ -48,29 +128,42 @@ func MainMenu(v *model.Vault, masterPassword string, vaultPath string) error {
 					continue
 				}
 
-				connPassword := selectedConn.Password
-				if !selectedConn.StorePassword || connPassword == "" {
-					passwordPrompt := promptui.Prompt{
-						Label: "Enter password for " + selectedConn.Username + "@" + selectedConn.Host,
-						Mask:  '*',
-					}
-					result, err := passwordPrompt.Run()
-					if err != nil {
-						// If the user cancelled the password prompt, continue to main menu
-						if err == promptui.ErrInterrupt {
-							continue
-						}
-						fmt.Printf("Password prompt failed %v\n", err)
+				ConnectAndSave(v, selectedConn, choice == menuConnectBackground, nil, masterPassword, vaultPath)
+			case menuConnectOverrides:
+				selectedConn, err := SelectConnection(v)
+				if err != nil {
+					if err == promptui.ErrInterrupt {
 						continue
 					}
-					connPassword = result
+					fmt.Printf("Error selecting connection: %v\n", err)
+					continue
+				}
+				overrides, err := PromptOverrides(v, selectedConn)
+				if err != nil {
+					continue
 				}
 
-				fmt.Printf("Connecting to %s...\n", selectedConn.Name)
-				if err := rdp.Run(selectedConn, connPassword); err != nil {
-					fmt.Printf("RDP connection failed: %v\n", err)
+				ConnectAndSave(v, selectedConn, false, overrides, masterPassword, vaultPath)
+			case menuSessions:
+				if err := ShowSessions(v); err != nil {
+					fmt.Printf("Error listing sessions: %v\n", err)
 				}
-			case 1: // Add new host
+			case menuKillSession:
+				if err := KillSession(v); err != nil {
+					if err == promptui.ErrInterrupt {
+						continue
+					}
+					fmt.Printf("Error killing session: %v\n", err)
+				}
+			case menuWorkspaces:
+				if err := WorkspacesMenu(v, masterPassword, vaultPath); err != nil {
+					if err == promptui.ErrInterrupt {
+						continue
+					}
+					fmt.Printf("Workspace error: %v\n", err)
+				}
+			case menuAdd:
+				before := snapshotConnections(v)
 				if err := AddConnection(v); err != nil {
 					// If the user cancelled the operation, continue to main menu
 					if err == promptui.ErrInterrupt {
@@ -78,11 +171,12 @@ func MainMenu(v *model.Vault, masterPassword string, vaultPath string) error {
 					}
 					fmt.Printf("Error adding connection: %v\n", err)
 				} else {
-					if err := vault.SaveVault(vaultPath, v, masterPassword); err != nil {
+					if err := saveChanges(v, before, masterPassword, vaultPath); err != nil {
 						fmt.Printf("Error saving vault: %v\n", err)
 					}
 				}
-			case 2: // Edit existing host
+			case menuEdit:
+				before := snapshotConnections(v)
 				if err := EditConnection(v); err != nil {
 					// If the user cancelled the operation, continue to main menu
 					if err == promptui.ErrInterrupt {
@@ -90,29 +184,162 @@ func MainMenu(v *model.Vault, masterPassword string, vaultPath string) error {
 					}
 					fmt.Printf("Error editing connection: %v\n", err)
 				} else {
-					if err := vault.SaveVault(vaultPath, v, masterPassword); err != nil {
+					if err := saveChanges(v, before, masterPassword, vaultPath); err != nil {
+						fmt.Printf("Error saving vault: %v\n", err)
+					}
+				}
+			case menuDuplicate:
+				before := snapshotConnections(v)
+				if err := DuplicateConnection(v); err != nil {
+					if err == promptui.ErrInterrupt {
+						continue
+					}
+					fmt.Printf("Error duplicating connection: %v\n", err)
+				} else {
+					if err := saveChanges(v, before, masterPassword, vaultPath); err != nil {
+						fmt.Printf("Error saving vault: %v\n", err)
+					}
+				}
+			case menuBulkEdit:
+				before := snapshotConnections(v)
+				if err := BulkEdit(v); err != nil {
+					if err == promptui.ErrInterrupt {
+						continue
+					}
+					fmt.Printf("Error editing hosts: %v\n", err)
+				} else {
+					// All changed hosts are written in a single save
+					if err := saveChanges(v, before, masterPassword, vaultPath); err != nil {
 						fmt.Printf("Error saving vault: %v\n", err)
 					}
 				}
-			case 3: // Delete host
+			case menuDelete:
+				before := snapshotConnections(v)
 				if err := DeleteConnection(v); err != nil {
 					// If the user cancelled the operation, continue to main menu
 					if err == promptui.ErrInterrupt {
 						continue
 					}
 					fmt.Printf("Error deleting connection: %v\n", err)
+				} else {
+					if err := saveChanges(v, before, masterPassword, vaultPath); err != nil {
+						fmt.Printf("Error saving vault: %v\n", err)
+					}
+				}
+			case menuTrashRestore:
+				before := snapshotConnections(v)
+				if err := RestoreFromTrash(v); err != nil {
+					if err == promptui.ErrInterrupt {
+						continue
+					}
+					fmt.Printf("Error restoring connection: %v\n", err)
+				} else {
+					if err := saveChanges(v, before, masterPassword, vaultPath); err != nil {
+						fmt.Printf("Error saving vault: %v\n", err)
+					}
+				}
+			case menuTrashEmpty:
+				if err := EmptyTrash(v, vaultPath); err != nil {
+					fmt.Printf("Error emptying trash: %v\n", err)
 				} else {
 					if err := vault.SaveVault(vaultPath, v, masterPassword); err != nil {
 						fmt.Printf("Error saving vault: %v\n", err)
 					}
 				}
-			case 4: // Show vault
+			case menuShow:
 				if err := ShowVault(v); err != nil {
 					fmt.Printf("Error showing vault: %v\n", err)
 				}
-			case 5: // Quit
+			case menuTemplates:
+				if err := TemplatesMenu(v, masterPassword, vaultPath); err != nil {
+					if err == promptui.ErrInterrupt {
+						continue
+					}
+					fmt.Printf("Template error: %v\n", err)
+				}
+			case menuSortOrder:
+				if err := ChooseSortOrder(v); err != nil {
+					if err == promptui.ErrInterrupt {
+						continue
+					}
+					fmt.Printf("Error changing sort order: %v\n", err)
+				} else {
+					if err := vault.SaveVault(vaultPath, v, masterPassword); err != nil {
+						fmt.Printf("Error saving vault: %v\n", err)
+					}
+				}
+			case menuSwitchVault:
+				newVault, newPassword, newPath, err := SwitchVault(vaultPath)
+				if err != nil {
+					if err == promptui.ErrInterrupt {
+						continue
+					}
+					fmt.Printf("Error switching vault: %v\n", err)
+					continue
+				}
+				v, masterPassword, vaultPath = newVault, newPassword, newPath
+			case menuCopyToVault:
+				if err := CopyToVault(v, vaultPath); err != nil {
+					if err == promptui.ErrInterrupt {
+						continue
+					}
+					fmt.Printf("Error copying hosts: %v\n", err)
+				}
+			case menuQuit:
 				fmt.Println("Goodbye!")
 				return nil
 		}
 	}
 }
+
+// ConnectAndSave connects to c in the foreground or background, records the connect in the
+// vault and its audit log, and offers to fix a rejected stored password. overrides, if non-nil,
+// are connect-time overrides applied on top of the settings c inherits. It returns the error the
+// connection failed with, if any.
+func ConnectAndSave(v *model.Vault, c *model.Connection, background bool, overrides *model.Defaults, masterPassword string, vaultPath string) error {
+	connect := ConnectToHost
+	if background {
+		connect = ConnectInBackground
+	}
+
+	var started time.Time
+	var sessionID string
+	launched := func(sess *session.Session) {
+		started = time.Now()
+		sessionID = RecordLaunch(vaultPath, v, c, sess)
+		if err := vault.SaveVault(vaultPath, v, masterPassword); err != nil {
+			fmt.Printf("Error saving vault: %v\n", err)
+		}
+	}
+
+	// Connect with the settings c inherits from its defaults, group and template
+	effective, _ := inherit.Effective(v, c, overrides)
+	err := connect(v, &effective, launched)
+	if !background && !started.IsZero() {
+		recordConnectEnd(vaultPath, v, c, sessionID, started, err)
+		if err := vault.SaveVault(vaultPath, v, masterPassword); err != nil {
+			fmt.Printf("Error saving vault: %v\n", err)
+		}
+	}
+	if err == nil {
+		return nil
+	}
+	// If the user cancelled the password prompt, return to the main menu
+	if err == promptui.ErrInterrupt {
+		return nil
+	}
+	fmt.Printf("RDP connection failed: %v\n", err)
+
+	before := snapshotConnections(v)
+	updated, updateErr := OfferPasswordUpdate(c, err)
+	if updateErr != nil {
+		fmt.Printf("Error updating password: %v\n", updateErr)
+	} else if updated {
+		if err := saveChanges(v, before, masterPassword, vaultPath); err != nil {
+			fmt.Printf("Error saving vault: %v\n", err)
+		} else {
+			fmt.Printf("Password for '%s' updated.\n", c.Name)
+		}
+	}
+	return err
+}
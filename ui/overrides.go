@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+
+	"rdpctl/inherit"
+	"rdpctl/model"
+	"rdpctl/security"
+)
+
+// PromptOverrides asks for connect-time overrides of the domain, username, RD Gateway and extra
+// arguments of c, the last layer of inherit.Effective. They apply to this connect only and are
+// never stored. Blank answers keep the effective value.
+func PromptOverrides(v *model.Vault, c *model.Connection) (*model.Defaults, error) {
+	effective, _ := inherit.Effective(v, c, nil)
+	overrides := &model.Defaults{}
+
+	fields := []struct {
+		label   string
+		current string
+		value   *string
+	}{
+		{"Domain", effective.Domain, &overrides.Domain},
+		{"Username", effective.Username, &overrides.Username},
+		{"RD Gateway", effective.Gateway, &overrides.Gateway},
+	}
+	for _, f := range fields {
+		prompt := promptui.Prompt{
+			Label: fmt.Sprintf("%s for this connect (blank keeps: %s)", f.label, f.current),
+		}
+		input, err := prompt.Run()
+		if err != nil {
+			return nil, err
+		}
+		*f.value = strings.TrimSpace(input)
+	}
+
+	argsPrompt := promptui.Prompt{
+		Label: fmt.Sprintf("Extra xfreerdp arguments for this connect (comma-separated, blank keeps: %s)", strings.Join(security.MaskArgs(effective.ExtraArgs), " ")),
+	}
+	input, err := argsPrompt.Run()
+	if err != nil {
+		return nil, err
+	}
+	overrides.ExtraArgs = splitArgs(input)
+	return overrides, nil
+}
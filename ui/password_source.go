@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// PasswordSource supplies the master password without an interactive prompt.
+type PasswordSource func() (string, error)
+
+// MasterPasswordSource replaces the interactive master password prompt when set. Because such a
+// source cannot be asked again, a wrong password fails the unlock at once instead of retrying.
+var MasterPasswordSource PasswordSource
+
+// PasswordFromReader returns a source reading the first line of r, as with --password-stdin.
+func PasswordFromReader(r io.Reader) PasswordSource {
+	return func() (string, error) {
+		password, err := firstLine(r)
+		if err != nil {
+			return "", fmt.Errorf("failed to read master password from stdin: %w", err)
+		}
+		return password, nil
+	}
+}
+
+// PasswordFromFile returns a source reading the first line of the file at path. It warns if other
+// users can read the file.
+func PasswordFromFile(path string) PasswordSource {
+	return func() (string, error) {
+		file, err := os.Open(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to open password file: %w", err)
+		}
+		defer file.Close()
+
+		if info, err := file.Stat(); err == nil && info.Mode().Perm()&0077 != 0 {
+			fmt.Fprintf(os.Stderr, "Warning: password file %s is accessible by other users (mode %v).\n", path, info.Mode().Perm())
+		}
+
+		password, err := firstLine(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read password file %s: %w", path, err)
+		}
+		return password, nil
+	}
+}
+
+// PasswordFromCommand returns a source running command with the shell and reading the first line
+// of its output, e.g. "pass show rdpctl" or an askpass dialog. The command's stdin and stderr stay
+// attached to the terminal so it can prompt.
+func PasswordFromCommand(command string) PasswordSource {
+	return func() (string, error) {
+		var stdout bytes.Buffer
+		cmd := exec.Command("sh", "-c", command)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = &stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("password command %q failed: %w", command, err)
+		}
+
+		password, err := firstLine(&stdout)
+		if err != nil {
+			return "", fmt.Errorf("password command %q: %w", command, err)
+		}
+		return password, nil
+	}
+}
+
+// firstLine returns the first line of r without its line ending. An empty password is an error.
+// It reads one byte at a time so that nothing after the line is consumed: later prompts of a
+// script may read their answers from the same stdin.
+func firstLine(r io.Reader) (string, error) {
+	var buf []byte
+	b := make([]byte, 1)
+	for {
+		n, err := r.Read(b)
+		if n > 0 {
+			if b[0] == '\n' {
+				break
+			}
+			buf = append(buf, b[0])
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	line := strings.TrimRight(string(buf), "\r")
+	if line == "" {
+		return "", fmt.Errorf("no password given")
+	}
+	return line, nil
+}
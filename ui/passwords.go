@@ -0,0 +1,75 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/manifoldco/promptui"
+
+	"rdpctl/clipboard"
+	"rdpctl/model"
+	"rdpctl/passwords"
+)
+
+// passwordGenerate is the store-password choice that generates the new password.
+const passwordGenerate = "Yes, generate a new one"
+
+// Choices after a password has been generated.
+const (
+	generatedUse        = "Use this password"
+	generatedAnother    = "Generate another"
+	generatedPassphrase = "Generate a passphrase instead"
+	generatedCharacters = "Generate random characters instead"
+)
+
+// promptGeneratedPassword generates passwords with passwords.DefaultOptions until the user accepts
+// one. Each is shown, so it can be set on the server, and copied to the clipboard when possible.
+func promptGeneratedPassword() (string, error) {
+	opts := passwords.DefaultOptions
+	for {
+		password, err := passwords.Generate(opts)
+		if err != nil {
+			return "", err
+		}
+
+		copied := ""
+		if clipboard.Copy(password) == nil {
+			copied = ", copied to the clipboard"
+		}
+		fmt.Printf("Generated password: %s (%.0f bits%s)\n", password, passwords.Entropy(opts), copied)
+
+		toggle := generatedPassphrase
+		if opts.Words > 0 {
+			toggle = generatedCharacters
+		}
+		choicePrompt := promptui.Select{
+			Label: "Generated password",
+			Items: []string{generatedUse, generatedAnother, toggle},
+		}
+		_, choice, err := choicePrompt.Run()
+		if err != nil {
+			return "", fmt.Errorf("prompt failed: %w", err)
+		}
+
+		switch choice {
+		case generatedUse:
+			return password, nil
+		case generatedPassphrase:
+			opts.Words = 6
+		case generatedCharacters:
+			opts.Words = 0
+		}
+	}
+}
+
+// warnStalePasswords lists the stored passwords that are older than passwords.MaxAge.
+func warnStalePasswords(v *model.Vault) {
+	stale := passwords.Stale(v, time.Now())
+	if len(stale) == 0 {
+		return
+	}
+	fmt.Printf("Warning: %d stored passwords are older than %d days:\n", len(stale), int(passwords.MaxAge.Hours()/24))
+	for _, c := range stale {
+		fmt.Printf("  - %s (changed %s)\n", c.Name, c.PasswordChangedAt.Format("2006-01-02"))
+	}
+}
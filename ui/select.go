@@ -3,39 +3,59 @@ package ui
 import (
 	"fmt"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/manifoldco/promptui"
 
+	"rdpctl/fuzzy"
 	"rdpctl/model"
+	"rdpctl/usage"
 )
 
+// selectEntry is a row of the connection selector. Searching rewrites the rows in rank order, since
+// promptui only filters its items and keeps their order.
+type selectEntry struct {
+	*model.Connection
+	NameMatches []int // Rune indexes of the name characters matched by the search, for highlighting
+	HostMatches []int // Rune indexes of the host characters matched by the search
+	matched     bool
+}
+
+// matchStyle highlights the characters a search matched.
+var matchStyle = promptui.Styler(promptui.FGCyan, promptui.FGBold)
+
 // SelectConnection prompts the user to select a connection from the vault.
+// Searching matches fuzzily across the name, host, username, domain, group, tags and notes, best
+// match first (see fuzzy.Rank), and highlights the matched characters.
 // It returns the selected connection and an error if any.
 func SelectConnection(v *model.Vault) (*model.Connection, error) {
 	if len(v.Connections) == 0 {
 		return nil, fmt.Errorf("no connections available. Please add a new host first.")
 	}
 
-	templates := &promptui.SelectTemplates{
-		Label:    "{{ . }}",
-		Active:   "\U000027A4 {{ .Name | green }} ({{ .Host }})",
-		Inactive: "  {{ .Name | faint }} ({{ .Host | faint }})",
-		Selected: "{{ .Name | green }} ({{ .Host }})",
+	connections := usage.Sorted(v.Connections, usage.SortOrder(v.SortOrder), time.Now())
+	entries := make([]*selectEntry, len(connections))
+	for i, c := range connections {
+		entries[i] = &selectEntry{Connection: c, matched: true}
 	}
 
+	// promptui asks about every item in order for each search, so rank once on the first item.
+	// Clearing the search shows all items again without asking, in the order of the last search.
+	ranked := ""
 	searcher := func(input string, index int) bool {
-		connection := v.Connections[index]
-		name := strings.ToLower(connection.Name)
-		host := strings.ToLower(connection.Host)
-		input = strings.ToLower(input)
-		return strings.Contains(name, input) || strings.Contains(host, input)
+		if index == 0 && input != ranked {
+			rankEntries(entries, connections, input)
+			ranked = input
+		}
+		return entries[index].matched
 	}
 
 	prompt := promptui.Select{
 		Label:     "Select connection",
-		Items:     v.Connections,
-		Templates: templates,
-		Size:      10,
+		Items:     entries,
+		Templates: connectionTemplates(),
+		Size:      PageSize,
 		Searcher:  searcher,
 	}
 
@@ -45,5 +65,126 @@ func SelectConnection(v *model.Vault) (*model.Connection, error) {
 		return nil, fmt.Errorf("connection selection failed %w", err)
 	}
 
-	return &v.Connections[i], nil
+	return entries[i].Connection, nil
+}
+
+// SelectMatch prompts the user to pick one of the ranked results of a search that matched more than
+// one connection.
+func SelectMatch(query string, results []fuzzy.Result) (*model.Connection, error) {
+	entries := make([]*selectEntry, len(results))
+	for i, r := range results {
+		entries[i] = &selectEntry{Connection: r.Connection, NameMatches: r.NamePositions, HostMatches: r.HostPositions, matched: true}
+	}
+
+	prompt := promptui.Select{
+		Label:     fmt.Sprintf("%d connections match '%s'", len(results), query),
+		Items:     entries,
+		Templates: connectionTemplates(),
+		Size:      PageSize,
+	}
+	i, _, err := prompt.Run()
+	if err != nil {
+		return nil, fmt.Errorf("connection selection failed %w", err)
+	}
+	return entries[i].Connection, nil
+}
+
+// rankEntries rewrites entries with the connections matching query, best first, followed by the
+// connections that do not match in their listed order.
+func rankEntries(entries []*selectEntry, connections []*model.Connection, query string) {
+	results := fuzzy.Rank(connections, query, time.Now())
+	matched := make(map[*model.Connection]bool, len(results))
+	for i, r := range results {
+		*entries[i] = selectEntry{Connection: r.Connection, NameMatches: r.NamePositions, HostMatches: r.HostPositions, matched: true}
+		matched[r.Connection] = true
+	}
+	i := len(results)
+	for _, c := range connections {
+		if !matched[c] {
+			*entries[i] = selectEntry{Connection: c}
+			i++
+		}
+	}
+}
+
+// connectionTemplates shows a connection's name and host, highlighting the characters a search
+// matched.
+func connectionTemplates() *promptui.SelectTemplates {
+	funcs := template.FuncMap{"highlight": highlightMatches}
+	for name, f := range promptui.FuncMap {
+		funcs[name] = f
+	}
+
+	return &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "\U000027A4 {{ highlight .Name .NameMatches \"green\" }} ({{ highlight .Host .HostMatches \"\" }})",
+		Inactive: "  {{ highlight .Name .NameMatches \"faint\" }} ({{ highlight .Host .HostMatches \"faint\" }})",
+		Selected: "{{ .Name | green }} ({{ .Host }})",
+		FuncMap:  funcs,
+	}
+}
+
+// highlightMatches renders text in the named promptui style (none if empty), and the runes at the
+// given positions in matchStyle.
+func highlightMatches(text string, positions []int, style string) string {
+	base := func(v interface{}) string { return fmt.Sprint(v) }
+	if f, ok := promptui.FuncMap[style].(func(interface{}) string); ok {
+		base = f
+	}
+	if len(positions) == 0 {
+		return base(text)
+	}
+
+	matched := make(map[int]bool, len(positions))
+	for _, p := range positions {
+		matched[p] = true
+	}
+
+	// Style runs of matched and unmatched runes as a whole, to keep the escape codes few
+	var b strings.Builder
+	runes := []rune(text)
+	for start := 0; start < len(runes); {
+		end := start + 1
+		for end < len(runes) && matched[end] == matched[start] {
+			end++
+		}
+		if matched[start] {
+			b.WriteString(matchStyle(string(runes[start:end])))
+		} else {
+			b.WriteString(base(string(runes[start:end])))
+		}
+		start = end
+	}
+	return b.String()
+}
+
+// ChooseSortOrder prompts for the order of the connection selector and stores it in the vault.
+func ChooseSortOrder(v *model.Vault) error {
+	items := []string{"vault order"}
+	for _, o := range usage.SortOrders {
+		items = append(items, string(o))
+	}
+
+	prompt := promptui.Select{
+		Label: fmt.Sprintf("Sort connections by (current: %s)", sortOrderLabel(v.SortOrder)),
+		Items: items,
+	}
+	i, _, err := prompt.Run()
+	if err != nil {
+		return err
+	}
+
+	if i == 0 {
+		v.SortOrder = string(usage.SortVault)
+	} else {
+		v.SortOrder = string(usage.SortOrders[i-1])
+	}
+	return nil
+}
+
+func sortOrderLabel(order string) string {
+	if order == "" {
+		return "vault order"
+	}
+	return order
 }
@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/manifoldco/promptui"
+
+	"rdpctl/model"
+	"rdpctl/session"
+)
+
+// RunningSession pairs a background session with the connection it was started for.
+type RunningSession struct {
+	session.Session
+	Name string
+	Host string
+}
+
+// RunningSessions returns the live background sessions, resolving their connections in v.
+// Sessions for connections no longer in the vault are reported with an "(unknown)" name.
+func RunningSessions(v *model.Vault) ([]RunningSession, error) {
+	store, err := session.DefaultStore()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	running := make([]RunningSession, 0, len(sessions))
+	for _, sess := range sessions {
+		rs := RunningSession{Session: sess, Name: "(unknown)"}
+		for _, conn := range v.Connections {
+			if conn.ID == sess.ConnectionID {
+				rs.Name = conn.Name
+				rs.Host = conn.Host
+				break
+			}
+		}
+		running = append(running, rs)
+	}
+	return running, nil
+}
+
+// ShowSessions lists the RDP clients running in the background.
+func ShowSessions(v *model.Vault) error {
+	fmt.Println("\n--- Running Sessions ---")
+
+	running, err := RunningSessions(v)
+	if err != nil {
+		return err
+	}
+
+	if len(running) == 0 {
+		fmt.Println("No background sessions are running.")
+		return waitForEnter()
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "Name\tHost\tPID\tStarted\tUptime\tLog")
+	fmt.Fprintln(w, "----\t----\t---\t-------\t------\t---")
+	for _, rs := range running {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n",
+			rs.Name, rs.Host, rs.PID, rs.StartedAt.Format("2006-01-02 15:04"),
+			time.Since(rs.StartedAt).Round(time.Second), rs.LogFile)
+	}
+	w.Flush()
+
+	return waitForEnter()
+}
+
+// KillSession lets the user pick a running background session and terminates it.
+func KillSession(v *model.Vault) error {
+	fmt.Println("\n--- Kill Session ---")
+
+	running, err := RunningSessions(v)
+	if err != nil {
+		return err
+	}
+	if len(running) == 0 {
+		fmt.Println("No background sessions are running.")
+		return nil
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "\U000027A4 {{ .Name | green }} ({{ .Host }}, PID {{ .PID }})",
+		Inactive: "  {{ .Name | faint }} ({{ .Host | faint }}, PID {{ .PID }})",
+		Selected: "{{ .Name | green }} (PID {{ .PID }})",
+	}
+
+	prompt := promptui.Select{
+		Label:     "Select session to kill",
+		Items:     running,
+		Templates: templates,
+		Size:      PageSize,
+	}
+
+	i, _, err := prompt.Run()
+	if err != nil {
+		return err
+	}
+
+	if err := session.Kill(running[i].Session); err != nil {
+		return err
+	}
+	fmt.Printf("Sent terminate signal to %s (PID %d).\n", running[i].Name, running[i].PID)
+	return nil
+}
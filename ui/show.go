@@ -19,17 +19,19 @@ func ShowVault(v *model.Vault) error {
 	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-	fmt.Fprintln(w, "Name\tHost\tDomain\tUsername\tPassword\tExtra Args")
-	fmt.Fprintln(w, "----\t----\t------\t--------\t--------\t----------")
+	fmt.Fprintln(w, "Name\tHost\tGroup\tDomain\tUsername\tPassword\tExtra Args")
+	fmt.Fprintln(w, "----\t----\t-----\t------\t--------\t--------\t----------")
 
 	for _, conn := range v.Connections {
 		passwordDisplay := "(not stored)"
-		if conn.StorePassword {
+		if conn.PasswordRef != "" {
+			passwordDisplay = conn.PasswordRef
+		} else if conn.StorePassword {
 			passwordDisplay = conn.Password
 		}
 		extraArgs := strings.Join(conn.ExtraArgs, ", ")
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
-			conn.Name, conn.Host, conn.Domain, conn.Username, passwordDisplay, extraArgs)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			conn.Name, conn.Host, conn.Group, conn.Domain, conn.Username, passwordDisplay, extraArgs)
 	}
 	w.Flush()
 
@@ -0,0 +1,317 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/manifoldco/promptui"
+
+	"rdpctl/inherit"
+	"rdpctl/model"
+	"rdpctl/vault"
+)
+
+// Templates menu entries.
+const (
+	templatesVaultDefaults = "Edit vault defaults"
+	templatesGroupDefaults = "Edit group defaults"
+	templatesCreate        = "Create template"
+	templatesEdit          = "Edit template"
+	templatesDelete        = "Delete template"
+	templatesVariables     = "Edit vault variables"
+	templatesBack          = "Back"
+)
+
+// noTemplate is the template choice for connections that do not use one.
+const noTemplate = "(none)"
+
+// TemplatesMenu displays the templates and defaults submenu and saves the vault after changes.
+func TemplatesMenu(v *model.Vault, masterPassword string, vaultPath string) error {
+	prompt := promptui.Select{
+		Label: "Templates & defaults",
+		Items: []string{templatesVaultDefaults, templatesGroupDefaults, templatesCreate, templatesEdit, templatesDelete, templatesVariables, templatesBack},
+	}
+
+	_, choice, err := prompt.Run()
+	if err != nil {
+		return err
+	}
+
+	switch choice {
+	case templatesVaultDefaults:
+		fmt.Println("\n--- Vault Defaults ---")
+		if err := promptDefaults(&v.Defaults); err != nil {
+			return err
+		}
+	case templatesGroupDefaults:
+		if err := editGroupDefaults(v); err != nil {
+			return err
+		}
+	case templatesCreate:
+		if err := createTemplate(v); err != nil {
+			return err
+		}
+	case templatesEdit:
+		t, err := selectTemplate(v)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\n--- Edit Template '%s' ---\n", t.Name)
+		if err := promptDefaults(&t.Defaults); err != nil {
+			return err
+		}
+	case templatesDelete:
+		if err := deleteTemplate(v); err != nil {
+			return err
+		}
+	case templatesVariables:
+		if err := editVariable(v); err != nil {
+			return err
+		}
+	default:
+		return nil
+	}
+
+	return vault.SaveVault(vaultPath, v, masterPassword)
+}
+
+// promptDefaults prompts for every inheritable setting, using the current values as defaults.
+func promptDefaults(d *model.Defaults) error {
+	fields := []struct {
+		label string
+		value *string
+	}{
+		{"Domain", &d.Domain},
+		{"Username", &d.Username},
+		{"RD Gateway", &d.Gateway},
+	}
+	for _, f := range fields {
+		prompt := promptui.Prompt{
+			Label:   f.label + " (blank to leave unset)",
+			Default: *f.value,
+		}
+		value, err := prompt.Run()
+		if err != nil {
+			return fmt.Errorf("prompt failed: %w", err)
+		}
+		*f.value = strings.TrimSpace(value)
+	}
+
+	extraArgsPrompt := promptui.Prompt{
+		Label:   "Extra xfreerdp arguments (comma-separated, blank to leave unset)",
+		Default: strings.Join(d.ExtraArgs, ", "),
+	}
+	extraArgsInput, err := extraArgsPrompt.Run()
+	if err != nil {
+		return fmt.Errorf("prompt failed: %w", err)
+	}
+	d.ExtraArgs = splitArgs(extraArgsInput)
+
+	d.TOTP, err = promptTOTP(d.TOTP)
+	return err
+}
+
+// editGroupDefaults prompts for a group name and edits its defaults, creating them if needed.
+func editGroupDefaults(v *model.Vault) error {
+	groupPrompt := promptui.Prompt{
+		Label:    "Group",
+		Validate: requireInput,
+	}
+	group, err := groupPrompt.Run()
+	if err != nil {
+		return fmt.Errorf("prompt failed: %w", err)
+	}
+	group = strings.TrimSpace(group)
+
+	g := inherit.FindGroupDefaults(v, group)
+	if g == nil {
+		v.GroupDefaults = append(v.GroupDefaults, model.GroupDefaults{Group: group})
+		g = &v.GroupDefaults[len(v.GroupDefaults)-1]
+	}
+
+	fmt.Printf("\n--- Defaults for Group '%s' ---\n", g.Group)
+	return promptDefaults(&g.Defaults)
+}
+
+// createTemplate guides the user through creating a new template.
+func createTemplate(v *model.Vault) error {
+	fmt.Println("\n--- Create Template ---")
+
+	namePrompt := promptui.Prompt{
+		Label:    "Template name",
+		Validate: requireInput,
+	}
+	name, err := namePrompt.Run()
+	if err != nil {
+		return fmt.Errorf("prompt failed: %w", err)
+	}
+
+	t := model.Template{ID: uuid.New().String(), Name: strings.TrimSpace(name)}
+	if err := promptDefaults(&t.Defaults); err != nil {
+		return err
+	}
+
+	v.Templates = append(v.Templates, t)
+	fmt.Printf("Template '%s' created successfully!\n", t.Name)
+	return nil
+}
+
+// deleteTemplate guides the user through deleting a template. Connections using it keep
+// their own settings and stop inheriting from it.
+func deleteTemplate(v *model.Vault) error {
+	t, err := selectTemplate(v)
+	if err != nil {
+		return err
+	}
+
+	confirmPrompt := promptui.Prompt{
+		Label:     fmt.Sprintf("Are you sure you want to delete template '%s'? (yes/no)", t.Name),
+		IsConfirm: true,
+	}
+	if _, err := confirmPrompt.Run(); err != nil {
+		fmt.Println("Deletion cancelled.")
+		return nil
+	}
+
+	id, name := t.ID, t.Name
+	for i := range v.Templates {
+		if v.Templates[i].ID == id {
+			v.Templates = append(v.Templates[:i], v.Templates[i+1:]...)
+			break
+		}
+	}
+	for i := range v.Connections {
+		if v.Connections[i].TemplateID == id {
+			v.Connections[i].TemplateID = ""
+		}
+	}
+
+	fmt.Printf("Template '%s' deleted successfully!\n", name)
+	return nil
+}
+
+// selectTemplate prompts the user to select a template from the vault.
+func selectTemplate(v *model.Vault) (*model.Template, error) {
+	if len(v.Templates) == 0 {
+		return nil, fmt.Errorf("no templates available. Please create one first.")
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "\U000027A4 {{ .Name | green }}",
+		Inactive: "  {{ .Name | faint }}",
+		Selected: "{{ .Name | green }}",
+	}
+
+	prompt := promptui.Select{
+		Label:     "Select template",
+		Items:     v.Templates,
+		Templates: templates,
+		Size:      PageSize,
+	}
+
+	i, _, err := prompt.Run()
+	if err != nil {
+		return nil, fmt.Errorf("template selection failed %w", err)
+	}
+	return &v.Templates[i], nil
+}
+
+// editVariable prompts for a vault variable and its new value. A blank value removes the variable.
+func editVariable(v *model.Vault) error {
+	fmt.Println("\n--- Vault Variables ---")
+	for name, value := range v.Variables {
+		fmt.Printf("  %s = %s\n", name, value)
+	}
+
+	namePrompt := promptui.Prompt{
+		Label:    "Variable name (referenced as ${var:name})",
+		Validate: requireInput,
+	}
+	name, err := namePrompt.Run()
+	if err != nil {
+		return fmt.Errorf("prompt failed: %w", err)
+	}
+	name = strings.TrimSpace(name)
+
+	valuePrompt := promptui.Prompt{
+		Label:   "Value (blank to remove)",
+		Default: v.Variables[name],
+	}
+	value, err := valuePrompt.Run()
+	if err != nil {
+		return fmt.Errorf("prompt failed: %w", err)
+	}
+
+	if value == "" {
+		delete(v.Variables, name)
+		fmt.Printf("Variable '%s' removed.\n", name)
+		return nil
+	}
+	if v.Variables == nil {
+		v.Variables = map[string]string{}
+	}
+	v.Variables[name] = value
+	fmt.Printf("Variable '%s' set.\n", name)
+	return nil
+}
+
+// promptTemplate lets the user choose the template c inherits from, if the vault has any.
+func promptTemplate(v *model.Vault, c *model.Connection) error {
+	if len(v.Templates) == 0 {
+		return nil
+	}
+
+	items := []string{noTemplate}
+	cursor := 0
+	for i, t := range v.Templates {
+		items = append(items, t.Name)
+		if t.ID == c.TemplateID {
+			cursor = i + 1
+		}
+	}
+
+	prompt := promptui.Select{
+		Label:     "Template",
+		Items:     items,
+		CursorPos: cursor,
+	}
+	i, _, err := prompt.Run()
+	if err != nil {
+		return fmt.Errorf("prompt failed: %w", err)
+	}
+
+	c.TemplateID = ""
+	if i > 0 {
+		c.TemplateID = v.Templates[i-1].ID
+	}
+	return nil
+}
+
+// inheritHint describes the value a blank field falls back to, for use in prompt labels.
+func inheritHint(inherited string) string {
+	if inherited == "" {
+		return ""
+	}
+	return fmt.Sprintf(", blank inherits %s", inherited)
+}
+
+// parenthesize wraps a hint that starts with ", " in parentheses, or returns "" for no hint.
+func parenthesize(hint string) string {
+	if hint == "" {
+		return ""
+	}
+	return " (" + strings.TrimPrefix(hint, ", ") + ")"
+}
+
+// splitArgs splits a comma-separated argument list, trimming whitespace and dropping empty entries.
+func splitArgs(input string) []string {
+	var args []string
+	for _, arg := range strings.Split(input, ",") {
+		if arg = strings.TrimSpace(arg); arg != "" {
+			args = append(args, arg)
+		}
+	}
+	return args
+}
@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/manifoldco/promptui"
+
+	"rdpctl/clipboard"
+	"rdpctl/model"
+	"rdpctl/totp"
+)
+
+// totpMinValidity is how long a code must stay valid to be shown at connect time. A code closer to
+// expiring is skipped in favor of the next one, so there is time to type it.
+const totpMinValidity = 8 * time.Second
+
+// promptTOTP asks for a TOTP seed, an otpauth:// URI or the path of a QR code image holding one.
+// A blank answer keeps current and "-" removes it. It returns the value to store.
+func promptTOTP(current string) (string, error) {
+	label := "TOTP seed, otpauth:// URI or QR code image for RD Gateway MFA (optional)"
+	if current != "" {
+		label = "TOTP seed, otpauth:// URI or QR code image (blank keeps the current one, - removes it)"
+	}
+	totpPrompt := promptui.Prompt{
+		Label: label,
+		Validate: func(input string) error {
+			input = strings.TrimSpace(input)
+			if input == "" || input == "-" || isFile(input) {
+				return nil
+			}
+			_, err := totp.Parse(input)
+			return err
+		},
+	}
+	input, err := totpPrompt.Run()
+	if err != nil {
+		return "", fmt.Errorf("prompt failed: %w", err)
+	}
+
+	input = strings.TrimSpace(input)
+	switch {
+	case input == "":
+		return current, nil
+	case input == "-":
+		return "", nil
+	case isFile(input):
+		uri, err := totp.ReadQR(input)
+		if err != nil {
+			return "", err
+		}
+		if _, err := totp.Parse(uri); err != nil {
+			return "", fmt.Errorf("QR code in %s is not a TOTP key: %w", input, err)
+		}
+		fmt.Println("Read the TOTP key from the QR code.")
+		return uri, nil
+	default:
+		return input, nil
+	}
+}
+
+// isFile reports whether path names an existing regular file.
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// ShowTOTP prints the current TOTP code of c, if it has a TOTP key, with how long it stays valid,
+// and copies it to the clipboard when a clipboard tool is available. When the current code is about
+// to expire, it counts down to the next one first.
+func ShowTOTP(c *model.Connection) error {
+	if c.TOTP == "" {
+		return nil
+	}
+	key, err := totp.Parse(c.TOTP)
+	if err != nil {
+		return fmt.Errorf("invalid TOTP key for %s: %w", c.Name, err)
+	}
+
+	now := time.Now()
+	if expires := key.Expires(now); expires.Sub(now) < totpMinValidity {
+		for left := time.Until(expires); left > 0; left = time.Until(expires) {
+			fmt.Printf("\rNext TOTP code in %ds ", int(left.Round(time.Second)/time.Second))
+			time.Sleep(min(left, time.Second))
+		}
+		fmt.Println()
+		now = time.Now()
+	}
+
+	code := key.Code(now)
+	validity := key.Expires(now).Sub(now).Round(time.Second)
+	if err := clipboard.Copy(code); err == nil {
+		fmt.Printf("TOTP code for %s: %s (valid for %s, copied to the clipboard)\n", c.Name, code, validity)
+	} else {
+		fmt.Printf("TOTP code for %s: %s (valid for %s)\n", c.Name, code, validity)
+	}
+	return nil
+}
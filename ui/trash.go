@@ -0,0 +1,123 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/manifoldco/promptui"
+
+	"rdpctl/audit"
+	"rdpctl/model"
+	"rdpctl/trash"
+)
+
+// trashItem is a trash entry shown in the restore selector.
+type trashItem struct {
+	Name    string
+	Host    string
+	Deleted string
+}
+
+// RestoreFromTrash lets the user pick a deleted connection and moves it back into the vault.
+func RestoreFromTrash(v *model.Vault) error {
+	fmt.Println("\n--- Restore from Trash ---")
+
+	if len(v.Trash) == 0 {
+		fmt.Println("The trash is empty.")
+		return nil
+	}
+
+	// Most recently deleted first
+	items := make([]trashItem, len(v.Trash))
+	for i, item := range v.Trash {
+		items[len(items)-1-i] = trashItem{
+			Name:    item.Connection.Name,
+			Host:    item.Connection.Host,
+			Deleted: item.DeletedAt.Local().Format("2006-01-02 15:04"),
+		}
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "\U000027A4 {{ .Name | green }} ({{ .Host }}, deleted {{ .Deleted }})",
+		Inactive: "  {{ .Name | faint }} ({{ .Host | faint }}, deleted {{ .Deleted }})",
+		Selected: "{{ .Name | green }}",
+	}
+
+	prompt := promptui.Select{
+		Label:     "Select host to restore",
+		Items:     items,
+		Templates: templates,
+		Size:      PageSize,
+	}
+
+	i, _, err := prompt.Run()
+	if err != nil {
+		return err
+	}
+
+	c, err := trash.Restore(v, len(v.Trash)-1-i)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Connection '%s' restored.\n", c.Name)
+	return nil
+}
+
+// EmptyTrash permanently removes every connection in the trash after confirmation, recording each
+// in the audit log. The caller must save the vault.
+func EmptyTrash(v *model.Vault, vaultPath string) error {
+	fmt.Println("\n--- Empty Trash ---")
+
+	if len(v.Trash) == 0 {
+		fmt.Println("The trash is empty.")
+		return nil
+	}
+
+	confirmPrompt := promptui.Prompt{
+		Label:     fmt.Sprintf("Permanently delete the %d connection(s) in the trash? (yes/no)", len(v.Trash)),
+		IsConfirm: true,
+	}
+	if _, err := confirmPrompt.Run(); err != nil {
+		fmt.Println("Trash not emptied.")
+		return nil
+	}
+
+	removed := trash.Empty(v)
+	recordPurge(vaultPath, v, removed, "trash emptied")
+	fmt.Printf("%d connection(s) permanently deleted.\n", len(removed))
+	return nil
+}
+
+// purgeExpiredTrash permanently removes connections whose retention period in the trash has run
+// out. The caller must save the vault.
+func purgeExpiredTrash(vaultPath string, v *model.Vault) {
+	removed := trash.Purge(v, time.Now())
+	if len(removed) == 0 {
+		return
+	}
+	recordPurge(vaultPath, v, removed, "retention period expired")
+	// Unlocks of scripted subcommands pass through here too, so keep stdout clean
+	fmt.Fprintf(os.Stderr, "Permanently deleted %d connection(s) that were in the trash for longer than %d days.\n",
+		len(removed), int(trash.Retention.Hours()/24))
+}
+
+// recordPurge writes a purge event for each permanently deleted connection.
+func recordPurge(vaultPath string, v *model.Vault, removed []model.Connection, detail string) {
+	events := make([]audit.Event, len(removed))
+	for i := range removed {
+		events[i] = audit.NewEvent(audit.EventPurge).ForConnection(&removed[i])
+		events[i].Detail = detail
+	}
+	recordAudit(vaultPath, v, events...)
+}
+
+// trashExpiry describes how long a trashed connection can still be restored.
+func trashExpiry(item *model.TrashedConnection) string {
+	expires := trash.Expires(*item)
+	if expires.IsZero() {
+		return " It stays there until the trash is emptied."
+	}
+	return fmt.Sprintf(" It can be restored until %s.", expires.Local().Format("2006-01-02"))
+}
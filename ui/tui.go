@@ -0,0 +1,655 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/manifoldco/promptui"
+
+	"rdpctl/audit"
+	"rdpctl/clipboard"
+	"rdpctl/config"
+	"rdpctl/fuzzy"
+	"rdpctl/inherit"
+	"rdpctl/model"
+	"rdpctl/secrets"
+	"rdpctl/security"
+	"rdpctl/session"
+	"rdpctl/usage"
+)
+
+// tuiAction is an action the full-screen interface hands back to RunTUI. Actions that prompt
+// run in the regular terminal, outside the full-screen view.
+type tuiAction int
+
+const (
+	tuiQuit tuiAction = iota + 1
+	tuiConnect
+	tuiConnectBackground
+	tuiConnectOverrides
+	tuiAdd
+	tuiEdit
+	tuiDelete
+	tuiClone
+	tuiRelock
+)
+
+// sessionRefresh is how often the full-screen interface checks for running background sessions.
+const sessionRefresh = 2 * time.Second
+
+var (
+	tuiTitleStyle    = lipgloss.NewStyle().Bold(true)
+	tuiHeadingStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("4"))
+	tuiSelectedStyle = lipgloss.NewStyle().Reverse(true)
+	tuiFaintStyle    = lipgloss.NewStyle().Faint(true)
+	tuiRunningStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	tuiPlainStyle    = lipgloss.NewStyle()
+	tuiDetailStyle   = lipgloss.NewStyle().BorderStyle(lipgloss.NormalBorder()).BorderLeft(true).PaddingLeft(1)
+)
+
+// tuiRow is one line of the connection list: a group heading or a connection.
+type tuiRow struct {
+	heading string
+	conn    *model.Connection
+}
+
+// tuiModel is the state of the full-screen interface. It survives leaving and re-entering the
+// full-screen view around prompting actions.
+type tuiModel struct {
+	v          *model.Vault
+	vaultLabel string
+
+	rows     []tuiRow
+	cursor   int
+	offset   int
+	selected string // ID of the connection under the cursor, kept across rebuilds
+	grouped  bool
+
+	search    textinput.Model
+	searching bool
+	matches   map[string]fuzzy.Result // Search matches per connection ID, for highlighting
+
+	running   map[string]int // Background sessions per connection ID
+	status    string
+	width     int
+	height    int
+	lastInput time.Time
+	action    tuiAction
+}
+
+// sessionsMsg carries the number of running background sessions per connection ID.
+type sessionsMsg map[string]int
+
+// statusMsg replaces the status line.
+type statusMsg string
+
+// idleMsg is sent when the interface may have been idle for longer than IdleLock.
+type idleMsg struct{}
+
+// RunTUI shows the full-screen connection browser until the user quits. Connecting, adding,
+// editing, deleting and cloning leave the full-screen view for the regular prompts, then return
+// to the browser where it left off.
+func RunTUI(v *model.Vault, masterPassword string, vaultPath string) error {
+	search := textinput.New()
+	search.Prompt = "/"
+	search.Placeholder = "fuzzy search hosts, users, groups, tags, notes"
+
+	m := &tuiModel{v: v, search: search, running: map[string]int{}}
+	if name := VaultLabel(vaultPath); name != config.DefaultProfile {
+		m.vaultLabel = name
+	}
+
+	for {
+		m.action = 0
+		m.lastInput = time.Now()
+		m.rebuild()
+		final, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+		if err != nil {
+			return err
+		}
+		m = final.(*tuiModel)
+		m.status = ""
+
+		c := m.current()
+		before := snapshotConnections(v)
+		switch m.action {
+		case tuiQuit:
+			fmt.Println("Goodbye!")
+			return nil
+		case tuiRelock:
+			if err := relock(vaultPath, masterPassword); err != nil {
+				return err
+			}
+		case tuiConnect, tuiConnectBackground, tuiConnectOverrides:
+			var overrides *model.Defaults
+			if m.action == tuiConnectOverrides {
+				if overrides, err = PromptOverrides(v, c); err != nil {
+					m.status = "Cancelled."
+					break
+				}
+			}
+			if err := ConnectAndSave(v, c, m.action == tuiConnectBackground, overrides, masterPassword, vaultPath); err != nil {
+				m.status = fmt.Sprintf("RDP connection to %s failed: %v", c.Name, err)
+			} else if m.action == tuiConnectBackground {
+				m.status = fmt.Sprintf("Started %s in the background.", c.Name)
+			}
+		case tuiAdd:
+			m.status = m.saveAction(AddConnection(v), before, masterPassword, vaultPath)
+		case tuiClone:
+			m.status = m.saveAction(duplicateHost(v, c), before, masterPassword, vaultPath)
+		case tuiEdit:
+			m.status = m.saveAction(editHost(v, c), before, masterPassword, vaultPath)
+		case tuiDelete:
+			m.status = m.saveAction(deleteHost(v, c), before, masterPassword, vaultPath)
+		}
+	}
+}
+
+// saveAction saves the changes of a finished prompting action and returns the status line to show.
+// A newly added connection becomes the selected one.
+func (m *tuiModel) saveAction(err error, before []model.Connection, masterPassword string, vaultPath string) string {
+	if err != nil {
+		if errors.Is(err, promptui.ErrInterrupt) {
+			return "Cancelled."
+		}
+		return fmt.Sprintf("Error: %v", err)
+	}
+
+	events := audit.ChangeEvents(before, m.v.Connections)
+	if len(events) == 0 {
+		return "Nothing changed."
+	}
+	if err := saveChanges(m.v, before, masterPassword, vaultPath); err != nil {
+		return fmt.Sprintf("Error saving vault: %v", err)
+	}
+
+	e := events[0]
+	switch e.Type {
+	case audit.EventAdd:
+		m.selected = e.ConnectionID
+		return fmt.Sprintf("Added %s.", e.ConnectionName)
+	case audit.EventDelete:
+		return fmt.Sprintf("Moved %s to the trash.", e.ConnectionName)
+	default:
+		return fmt.Sprintf("Updated %s.", e.ConnectionName)
+	}
+}
+
+func (m *tuiModel) Init() tea.Cmd {
+	return tea.Batch(loadSessions, m.idleCheck(IdleLock))
+}
+
+// idleCheck schedules the next idle check after d, or none if the idle lock is disabled.
+func (m *tuiModel) idleCheck(d time.Duration) tea.Cmd {
+	if IdleLock <= 0 {
+		return nil
+	}
+	return tea.Tick(d, func(time.Time) tea.Msg { return idleMsg{} })
+}
+
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.scroll()
+		return m, nil
+	case sessionsMsg:
+		m.running = msg
+		return m, tea.Tick(sessionRefresh, func(time.Time) tea.Msg { return loadSessions() })
+	case statusMsg:
+		m.status = string(msg)
+		return m, nil
+	case idleMsg:
+		// Hide the hosts of an unattended screen; input in the meantime postpones the lock
+		idle := time.Since(m.lastInput)
+		if idle >= IdleLock {
+			return m.finish(tuiRelock)
+		}
+		return m, m.idleCheck(IdleLock - idle)
+	case tea.KeyMsg:
+		// Ask for the master password again before acting on input to an unattended screen
+		if IdleLock > 0 && time.Since(m.lastInput) > IdleLock {
+			return m.finish(tuiRelock)
+		}
+		m.lastInput = time.Now()
+		if m.searching {
+			return m.updateSearch(msg)
+		}
+		return m.updateList(msg)
+	}
+	return m, nil
+}
+
+// updateList handles keys while the connection list has the focus.
+func (m *tuiModel) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.status = ""
+	switch msg.String() {
+	case "ctrl+c", "q":
+		return m.finish(tuiQuit)
+	case "up", "k":
+		m.move(-1)
+	case "down", "j":
+		m.move(1)
+	case "pgup":
+		m.move(-m.listHeight())
+	case "pgdown":
+		m.move(m.listHeight())
+	case "home":
+		m.move(-len(m.rows))
+	case "end":
+		m.move(len(m.rows))
+	case "/":
+		m.searching = true
+		return m, m.search.Focus()
+	case "esc":
+		m.search.SetValue("")
+		m.rebuild()
+	case "g":
+		m.grouped = !m.grouped
+		m.rebuild()
+	case "a":
+		return m.finish(tuiAdd)
+	}
+
+	c := m.current()
+	if c == nil {
+		return m, nil
+	}
+	switch msg.String() {
+	case "enter":
+		return m.finish(tuiConnect)
+	case "b":
+		return m.finish(tuiConnectBackground)
+	case "o":
+		return m.finish(tuiConnectOverrides)
+	case "e":
+		return m.finish(tuiEdit)
+	case "d":
+		return m.finish(tuiDelete)
+	case "c":
+		return m.finish(tuiClone)
+	case "y":
+		m.status = "Copying password..."
+		return m, copyPassword(*c)
+	}
+	return m, nil
+}
+
+// updateSearch handles keys while the search field has the focus. The list follows every keystroke.
+func (m *tuiModel) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m.finish(tuiQuit)
+	case "esc":
+		m.search.SetValue("")
+		fallthrough
+	case "enter":
+		m.searching = false
+		m.search.Blur()
+		m.rebuild()
+		return m, nil
+	case "up", "down":
+		return m.updateList(msg)
+	}
+
+	var cmd tea.Cmd
+	m.search, cmd = m.search.Update(msg)
+	m.rebuild()
+	return m, cmd
+}
+
+// finish leaves the full-screen view so RunTUI can carry out action.
+func (m *tuiModel) finish(action tuiAction) (tea.Model, tea.Cmd) {
+	m.action = action
+	return m, tea.Quit
+}
+
+// rebuild recomputes the list rows from the vault, the search and the grouping, keeping the cursor
+// on the selected connection when it is still listed. A search lists the matching connections best
+// match first.
+func (m *tuiModel) rebuild() {
+	now := time.Now()
+	results := fuzzy.Rank(usage.Sorted(m.v.Connections, usage.SortOrder(m.v.SortOrder), now), m.search.Value(), now)
+	conns := make([]*model.Connection, len(results))
+	m.matches = make(map[string]fuzzy.Result, len(results))
+	for i, r := range results {
+		conns[i] = r.Connection
+		m.matches[r.Connection.ID] = r
+	}
+
+	m.rows = m.rows[:0]
+	if m.grouped {
+		// Groups in alphabetical order, hosts without a group last
+		sort.SliceStable(conns, func(i, j int) bool {
+			gi, gj := conns[i].Group, conns[j].Group
+			if (gi == "") != (gj == "") {
+				return gj == ""
+			}
+			return gi < gj
+		})
+		for i, c := range conns {
+			if i == 0 || c.Group != conns[i-1].Group {
+				heading := c.Group
+				if heading == "" {
+					heading = "(no group)"
+				}
+				m.rows = append(m.rows, tuiRow{heading: heading})
+			}
+			m.rows = append(m.rows, tuiRow{conn: c})
+		}
+	} else {
+		for _, c := range conns {
+			m.rows = append(m.rows, tuiRow{conn: c})
+		}
+	}
+
+	m.cursor = -1
+	for i, r := range m.rows {
+		if r.conn != nil && r.conn.ID == m.selected {
+			m.cursor = i
+		}
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+		m.move(0)
+	}
+	m.scroll()
+}
+
+// move moves the cursor by delta rows, skipping group headings.
+func (m *tuiModel) move(delta int) {
+	if len(m.rows) == 0 {
+		m.selected = ""
+		return
+	}
+	i := min(max(m.cursor+delta, 0), len(m.rows)-1)
+	step := 1
+	if delta < 0 {
+		step = -1
+	}
+	for i >= 0 && i < len(m.rows) && m.rows[i].conn == nil {
+		i += step
+	}
+	if i < 0 || i >= len(m.rows) {
+		// Only a heading lies in that direction, which is always followed by its first connection
+		i = min(max(m.cursor, 0), len(m.rows)-1)
+		for m.rows[i].conn == nil {
+			i++
+		}
+	}
+	m.cursor = i
+	m.selected = m.rows[i].conn.ID
+	m.scroll()
+}
+
+// scroll keeps the cursor inside the visible part of the list.
+func (m *tuiModel) scroll() {
+	height := m.listHeight()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+height {
+		m.offset = m.cursor - height + 1
+	}
+	// Show the heading of the first group when the top connection is selected
+	if m.cursor > 0 && m.offset == m.cursor && m.rows[m.cursor-1].conn == nil {
+		m.offset--
+	}
+	m.offset = max(m.offset, 0)
+}
+
+// current returns the connection under the cursor, or nil if the list is empty.
+func (m *tuiModel) current() *model.Connection {
+	if m.cursor < 0 || m.cursor >= len(m.rows) {
+		return nil
+	}
+	return m.rows[m.cursor].conn
+}
+
+// listHeight is the number of list rows that fit between the title and the footer.
+func (m *tuiModel) listHeight() int {
+	return max(m.height-4, 1)
+}
+
+func (m *tuiModel) View() string {
+	if m.width == 0 {
+		return ""
+	}
+
+	title := fmt.Sprintf("rdpctl: %d hosts", len(m.v.Connections))
+	if m.vaultLabel != "" {
+		title += fmt.Sprintf(" (vault: %s)", m.vaultLabel)
+	}
+	if sessions := m.sessionCount(); sessions > 0 {
+		title += tuiRunningStyle.Render(fmt.Sprintf("  %d running", sessions))
+	}
+
+	listWidth := max(m.width*2/5, 24)
+	height := m.listHeight()
+	list := lipgloss.NewStyle().Width(listWidth).Height(height).MaxHeight(height).Render(m.listView(listWidth, height))
+	detail := tuiDetailStyle.Width(max(m.width-listWidth-2, 10)).Height(height).MaxHeight(height).Render(m.detailView())
+
+	search := m.search.View()
+	if !m.searching && m.search.Value() == "" {
+		search = tuiFaintStyle.Render("/ to search")
+	}
+	help := tuiFaintStyle.Render("enter connect  b background  o override  a add  e edit  c clone  d delete  y copy password  g group  q quit")
+	if m.status != "" {
+		help = m.status
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		tuiTitleStyle.Render(title),
+		lipgloss.JoinHorizontal(lipgloss.Top, list, detail),
+		search,
+		help,
+	)
+}
+
+// listView renders the visible rows of the connection list.
+func (m *tuiModel) listView(width int, height int) string {
+	if len(m.rows) == 0 {
+		if len(m.v.Connections) == 0 {
+			return tuiFaintStyle.Render("No hosts yet. Press a to add one.")
+		}
+		return tuiFaintStyle.Render("No hosts match.")
+	}
+
+	var lines []string
+	for i := m.offset; i < len(m.rows) && i < m.offset+height; i++ {
+		r := m.rows[i]
+		if r.conn == nil {
+			lines = append(lines, tuiHeadingStyle.Render(truncate(r.heading, width)))
+			continue
+		}
+
+		marker := "  "
+		if m.running[r.conn.ID] > 0 {
+			marker = tuiRunningStyle.Render("● ")
+		}
+		if m.grouped {
+			marker = "  " + marker
+		}
+		name := truncate(r.conn.Name, width-lipgloss.Width(marker))
+		host := truncate(" "+r.conn.Host, width-lipgloss.Width(marker)-lipgloss.Width(name))
+		match := m.matches[r.conn.ID]
+		// The host is shown after a space
+		hostPositions := make([]int, len(match.HostPositions))
+		for j, p := range match.HostPositions {
+			hostPositions[j] = p + 1
+		}
+		nameStyle, hostStyle := tuiPlainStyle, tuiFaintStyle
+		if i == m.cursor {
+			nameStyle, hostStyle = tuiSelectedStyle, tuiSelectedStyle
+		}
+		lines = append(lines, marker+
+			tuiHighlight(name, r.conn.Name, match.NamePositions, nameStyle)+
+			tuiHighlight(host, " "+r.conn.Host, hostPositions, hostStyle))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// detailView renders the non-secret settings and notes of the connection under the cursor.
+func (m *tuiModel) detailView() string {
+	c := m.current()
+	if c == nil {
+		return ""
+	}
+	effective, _ := inherit.Effective(m.v, c, nil)
+
+	password := "asked for at connect"
+	if c.PasswordRef != "" {
+		password = "from " + c.PasswordRef
+	} else if c.StorePassword {
+		password = "stored"
+	}
+	template := ""
+	if t := inherit.FindTemplate(m.v, c.TemplateID); t != nil {
+		template = t.Name
+	}
+	lastConnected := "never"
+	if !c.LastConnectedAt.IsZero() {
+		lastConnected = c.LastConnectedAt.Local().Format("2006-01-02 15:04")
+	}
+
+	fields := [][2]string{
+		{"Name", c.Name},
+		{"Host", c.Host},
+		{"Group", c.Group},
+		{"Tags", strings.Join(c.Tags, ", ")},
+		{"Template", template},
+		{"Domain", effective.Domain},
+		{"Username", effective.Username},
+		{"Password", password},
+		{"RD Gateway", effective.Gateway},
+		{"Extra args", strings.Join(security.MaskArgs(effective.ExtraArgs), " ")},
+		{"Wake-on-LAN", c.MACAddress},
+		{"Last connect", fmt.Sprintf("%s (%d connects)", lastConnected, c.ConnectCount)},
+	}
+	if effective.TOTP != "" {
+		fields = append(fields, [2]string{"TOTP", "configured"})
+	}
+	if n := m.running[c.ID]; n > 0 {
+		fields = append(fields, [2]string{"Sessions", tuiRunningStyle.Render(fmt.Sprintf("%d running in the background", n))})
+	}
+
+	var lines []string
+	for _, f := range fields {
+		if f[1] == "" {
+			continue
+		}
+		lines = append(lines, tuiFaintStyle.Render(fmt.Sprintf("%-13s", f[0]))+f[1])
+	}
+	if c.Notes != "" {
+		lines = append(lines, "", tuiFaintStyle.Render("Notes"), c.Notes)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// sessionCount returns the number of running background sessions.
+func (m *tuiModel) sessionCount() int {
+	n := 0
+	for _, count := range m.running {
+		n += count
+	}
+	return n
+}
+
+// loadSessions counts the running background sessions of each connection. It reads only the
+// session store, so it may still run while RunTUI changes the vault.
+func loadSessions() tea.Msg {
+	counts := sessionsMsg{}
+	store, err := session.DefaultStore()
+	if err != nil {
+		return counts
+	}
+	sessions, err := store.List()
+	if err != nil {
+		return counts
+	}
+	for _, sess := range sessions {
+		counts[sess.ConnectionID]++
+	}
+	return counts
+}
+
+// copyPassword copies the password of c to the clipboard, fetching it from its secret store when
+// it has a reference.
+func copyPassword(c model.Connection) tea.Cmd {
+	return func() tea.Msg {
+		var password string
+		switch {
+		case c.PasswordRef != "":
+			p, err := secrets.Resolve(context.Background(), c.PasswordRef)
+			if err != nil {
+				return statusMsg(fmt.Sprintf("Could not fetch the password of %s: %v", c.Name, err))
+			}
+			password = p
+		case c.StorePassword && c.Password != "":
+			password = c.Password
+		default:
+			return statusMsg(fmt.Sprintf("%s has no stored password.", c.Name))
+		}
+		if err := clipboard.Copy(password); err != nil {
+			return statusMsg(fmt.Sprintf("Could not copy the password: %v", err))
+		}
+		return statusMsg(fmt.Sprintf("Password of %s copied to the clipboard.", c.Name))
+	}
+}
+
+// tuiHighlight renders s, which is full or its truncation, in style, and the runes at the given
+// positions of full in bold cyan.
+func tuiHighlight(s string, full string, positions []int, style lipgloss.Style) string {
+	runes := []rune(s)
+	kept := len(runes)
+	if s != full {
+		kept-- // The ellipsis
+	}
+	matched := make(map[int]bool, len(positions))
+	for _, p := range positions {
+		if p < kept {
+			matched[p] = true
+		}
+	}
+	if len(matched) == 0 {
+		return style.Render(s)
+	}
+
+	matchStyle := style.Faint(false).Foreground(lipgloss.Color("6")).Bold(true)
+	var b strings.Builder
+	for start := 0; start < len(runes); {
+		end := start + 1
+		for end < len(runes) && matched[end] == matched[start] {
+			end++
+		}
+		if matched[start] {
+			b.WriteString(matchStyle.Render(string(runes[start:end])))
+		} else {
+			b.WriteString(style.Render(string(runes[start:end])))
+		}
+		start = end
+	}
+	return b.String()
+}
+
+// truncate shortens s to at most width cells, marking the cut with an ellipsis.
+func truncate(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if lipgloss.Width(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && lipgloss.Width(string(runes))+1 > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
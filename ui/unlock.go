@@ -1,15 +1,26 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
 	"github.com/manifoldco/promptui"
 
+	"rdpctl/audit"
 	"rdpctl/model"
+	"rdpctl/session"
+	"rdpctl/timesheet"
 	"rdpctl/vault"
 )
 
+// MaxUnlockAttempts is how many master password attempts are allowed before giving up.
+var MaxUnlockAttempts = 3
+
+// KeyfilePath is the keyfile offered to vaults that require one, set from --keyfile or
+// RDPCTL_KEYFILE. When it is empty, such vaults prompt for the keyfile's path.
+var KeyfilePath string
+
 // UnlockFlow handles the process of unlocking the vault, including first-time setup.
 // It returns the unlocked vault, the master password used, and an error if any.
 func UnlockFlow(vaultPath string) (*model.Vault, string, error) {
@@ -42,36 +53,153 @@ func firstTimeSetup(vaultPath string) (*model.Vault, string, error) {
 		return nil, "", fmt.Errorf("passwords do not match")
 	}
 
-	v, err := vault.CreateNewVault(vaultPath, password)
+	// A keyfile given up front becomes a second factor of the new vault
+	var keyfileHash []byte
+	if KeyfilePath != "" {
+		keyfileHash, err = vault.HashKeyfile(KeyfilePath)
+		if err != nil {
+			return nil, "", err
+		}
+		fmt.Printf("The vault will require the keyfile %s as well as the master password.\n", KeyfilePath)
+	}
+
+	v, err := vault.CreateNewVault(vaultPath, password, keyfileHash)
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to create new vault: %w", err)
 	}
 
 	fmt.Println("New vault created and encrypted successfully!")
+	if err := startAudit(vaultPath, v, password, "vault created"); err != nil {
+		return nil, "", err
+	}
 	return v, password, nil
 }
 
 func unlockExistingVault(vaultPath string) (*model.Vault, string, error) {
+	keyfileHash, err := unlockKeyfile(vaultPath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if MasterPasswordSource != nil {
+		return unlockWithSource(vaultPath, keyfileHash)
+	}
+
 	fmt.Println("Vault found. Please enter your master password to unlock.")
 
-	const maxRetries = 3
-	for attempts := 0; attempts < maxRetries; attempts++ {
+	for attempts := 0; attempts < MaxUnlockAttempts; attempts++ {
 		password, err := promptForMasterPassword("Enter master password: ")
 		if err != nil {
 			return nil, "", err
 		}
 
-		v, err := vault.LoadVault(vaultPath, password)
+		v, err := vault.LoadVault(vaultPath, password, keyfileHash)
 		if err == nil {
 			fmt.Println("Vault unlocked successfully!")
+			// Close timesheet entries of sessions that ended while the vault was locked
+			timesheet.Reconcile(v, session.Alive)
+			if err := startAudit(vaultPath, v, password, ""); err != nil {
+				return nil, "", err
+			}
+			warnStalePasswords(v)
 			return v, password, nil
+		} else if errors.Is(err, vault.ErrWrongPassword) {
+			recordFailedUnlock(vaultPath)
+			fmt.Printf("Incorrect password. %d attempts remaining.\n", MaxUnlockAttempts-1-attempts)
 		} else {
-			fmt.Printf("Incorrect password. %d attempts remaining.\n", maxRetries-1-attempts)
+			return nil, "", err
 		}
 	}
 	return nil, "", fmt.Errorf("too many incorrect password attempts")
 }
 
+// unlockWithSource unlocks the vault with the password from MasterPasswordSource. It makes a single
+// attempt and prints nothing on success, so the output of scripted subcommands stays clean.
+func unlockWithSource(vaultPath string, keyfileHash []byte) (*model.Vault, string, error) {
+	password, err := MasterPasswordSource()
+	if err != nil {
+		return nil, "", err
+	}
+
+	v, err := vault.LoadVault(vaultPath, password, keyfileHash)
+	if errors.Is(err, vault.ErrWrongPassword) {
+		recordFailedUnlock(vaultPath)
+		return nil, "", vault.ErrWrongPassword
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	timesheet.Reconcile(v, session.Alive)
+	if err := startAudit(vaultPath, v, password, "non-interactive"); err != nil {
+		return nil, "", err
+	}
+	return v, password, nil
+}
+
+// unlockKeyfile returns the hash of the keyfile for the vault at vaultPath, or nil if the vault does
+// not require one. The keyfile is KeyfilePath, or asked for when that is not set.
+func unlockKeyfile(vaultPath string) ([]byte, error) {
+	required, err := vault.RequiresKeyfile(vaultPath)
+	if err != nil || !required {
+		return nil, err
+	}
+
+	path := KeyfilePath
+	if path == "" {
+		fmt.Println("This vault requires a keyfile as well as the master password.")
+		keyfilePrompt := promptui.Prompt{
+			Label:    "Path to keyfile",
+			Validate: requireInput,
+		}
+		path, err = keyfilePrompt.Run()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	hash, err := vault.HashKeyfile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("this vault requires a keyfile, and %s does not exist", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := vault.CheckKeyfile(vaultPath, hash); err != nil {
+		if errors.Is(err, vault.ErrKeyfileMismatch) {
+			recordFailedUnlock(vaultPath)
+			return nil, fmt.Errorf("keyfile %s does not match this vault", path)
+		}
+		return nil, err
+	}
+	return hash, nil
+}
+
+// relock asks for the master password again after the menu sat idle for longer than IdleLock.
+// The vault stays decrypted in memory, so the entry is checked against the password it was unlocked with.
+func relock(vaultPath string, masterPassword string) error {
+	fmt.Println("Vault locked after inactivity. Please enter your master password to continue.")
+
+	for attempts := 0; attempts < MaxUnlockAttempts; attempts++ {
+		password, err := promptForMasterPassword("Enter master password: ")
+		if err != nil {
+			return err
+		}
+		if password == masterPassword {
+			return nil
+		}
+		recordFailedUnlock(vaultPath)
+		fmt.Printf("Incorrect password. %d attempts remaining.\n", MaxUnlockAttempts-1-attempts)
+	}
+	return fmt.Errorf("too many incorrect password attempts")
+}
+
+// PromptPassword asks for a password other than the master password, such as the passphrase of
+// a file being imported or exported.
+func PromptPassword(label string) (string, error) {
+	return promptForMasterPassword(label)
+}
+
 func promptForMasterPassword(label string) (string, error) {
 	prompt := promptui.Prompt{
 		Label: label,
@@ -81,3 +209,30 @@ func promptForMasterPassword(label string) (string, error) {
 	
 	return result, err
 }
+
+// startAudit initializes the vault's audit log if needed, records the unlock, purges connections
+// kept in the trash past their retention period, and saves the vault so the log is anchored.
+func startAudit(vaultPath string, v *model.Vault, password string, detail string) error {
+	if _, err := audit.Init(audit.PathFor(vaultPath), v); err != nil {
+		fmt.Printf("Warning: failed to initialize audit log: %v\n", err)
+		return nil
+	}
+
+	e := audit.NewEvent(audit.EventUnlock)
+	e.Detail = detail
+	recordAudit(vaultPath, v, e)
+	purgeExpiredTrash(vaultPath, v)
+
+	if err := vault.SaveVault(vaultPath, v, password); err != nil {
+		return fmt.Errorf("failed to save vault: %w", err)
+	}
+	return nil
+}
+
+// recordFailedUnlock appends a failed unlock attempt to the audit log, which does not need the vault key.
+func recordFailedUnlock(vaultPath string) {
+	err := audit.RecordLocked(audit.PathFor(vaultPath), audit.NewEvent(audit.EventUnlockFailed))
+	if err != nil && !errors.Is(err, audit.ErrNotInitialized) {
+		fmt.Printf("Warning: failed to write audit log: %v\n", err)
+	}
+}
@@ -0,0 +1,203 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+
+	"rdpctl/config"
+	"rdpctl/model"
+	"rdpctl/transfer"
+)
+
+// Vault selector entries besides the existing profiles.
+const (
+	vaultNewProfile = "New vault profile"
+	vaultOtherFile  = "Other vault file"
+)
+
+// VaultLabel names the vault at vaultPath for display: its profile name, or its path.
+func VaultLabel(vaultPath string) string {
+	configDir, err := config.ConfigDir()
+	if err != nil {
+		return vaultPath
+	}
+	return config.ProfileName(configDir, vaultPath)
+}
+
+// SwitchVault lets the user pick another vault profile or file and unlocks it, creating it if it
+// does not exist yet. It returns the unlocked vault, its master password and its path.
+func SwitchVault(currentPath string) (*model.Vault, string, string, error) {
+	fmt.Println("\n--- Switch Vault ---")
+
+	path, err := selectVault("Switch to vault", currentPath)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	v, masterPassword, err := UnlockFlow(path)
+	if err != nil {
+		return nil, "", "", err
+	}
+	fmt.Printf("Switched to vault '%s'.\n", VaultLabel(path))
+	return v, masterPassword, path, nil
+}
+
+// CopyToVault copies connections chosen from v into another vault, which is unlocked with its
+// own master password and saved once all copies are made.
+func CopyToVault(v *model.Vault, vaultPath string) error {
+	fmt.Println("\n--- Copy Hosts to Another Vault ---")
+
+	if len(v.Connections) == 0 {
+		return fmt.Errorf("no connections available. Please add a new host first.")
+	}
+
+	targetPath, err := selectVault("Copy to vault", vaultPath)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Unlocking destination vault '%s'.\n", VaultLabel(targetPath))
+	target, targetPassword, err := UnlockFlow(targetPath)
+	if err != nil {
+		return err
+	}
+	before := snapshotConnections(target)
+
+	copied := 0
+	for {
+		addPrompt := promptui.Select{
+			Label: fmt.Sprintf("%d connections copied", copied),
+			Items: []string{"Copy a connection", "Done"},
+		}
+		_, choice, err := addPrompt.Run()
+		if err != nil {
+			return fmt.Errorf("prompt failed: %w", err)
+		}
+		if choice == "Done" {
+			break
+		}
+
+		conn, err := SelectConnection(v)
+		if err != nil {
+			return err
+		}
+
+		if _, err := transfer.Copy(v, target, conn); err != nil {
+			var dup *transfer.DuplicateError
+			if errors.As(err, &dup) {
+				fmt.Printf("Skipped: %v\n", err)
+				continue
+			}
+			return err
+		}
+		fmt.Printf("Copied '%s'.\n", conn.Name)
+		copied++
+	}
+
+	if copied == 0 {
+		fmt.Println("Nothing copied.")
+		return nil
+	}
+	if err := saveChanges(target, before, targetPassword, targetPath); err != nil {
+		return fmt.Errorf("failed to save destination vault: %w", err)
+	}
+	fmt.Printf("%d connections copied to '%s'.\n", copied, VaultLabel(targetPath))
+	return nil
+}
+
+// selectVault prompts for a vault profile other than the one at exclude, a new profile, or a vault
+// file by path, and returns the chosen vault's path.
+func selectVault(label string, exclude string) (string, error) {
+	configDir, err := config.EnsureConfigDir()
+	if err != nil {
+		return "", err
+	}
+	profiles, err := config.Profiles(configDir)
+	if err != nil {
+		return "", err
+	}
+
+	items := []string{}
+	paths := map[string]string{}
+	for _, p := range profiles {
+		if p.Path == exclude {
+			continue
+		}
+		if _, err := os.Stat(p.Path); err != nil {
+			continue
+		}
+		items = append(items, p.Name)
+		paths[p.Name] = p.Path
+	}
+	items = append(items, vaultNewProfile, vaultOtherFile)
+
+	prompt := promptui.Select{
+		Label: label,
+		Items: items,
+		Size:  PageSize,
+	}
+	_, choice, err := prompt.Run()
+	if err != nil {
+		return "", err
+	}
+
+	switch choice {
+	case vaultNewProfile:
+		return promptNewProfile(configDir)
+	case vaultOtherFile:
+		pathPrompt := promptui.Prompt{
+			Label: "Path to vault file",
+			Validate: func(input string) error {
+				if err := requireInput(input); err != nil {
+					return err
+				}
+				if path, err := filepath.Abs(strings.TrimSpace(input)); err == nil && path == exclude {
+					return fmt.Errorf("that is the current vault")
+				}
+				return nil
+			},
+		}
+		path, err := pathPrompt.Run()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Abs(strings.TrimSpace(path))
+	default:
+		return paths[choice], nil
+	}
+}
+
+// promptNewProfile asks for the name of a new vault profile and returns the path its vault will use.
+func promptNewProfile(configDir string) (string, error) {
+	namePrompt := promptui.Prompt{
+		Label: "Profile name",
+		Validate: func(input string) error {
+			path, err := config.ProfilePath(configDir, strings.TrimSpace(input))
+			if err != nil {
+				return err
+			}
+			if _, err := os.Stat(path); err == nil {
+				return fmt.Errorf("profile already exists")
+			}
+			return nil
+		},
+	}
+	name, err := namePrompt.Run()
+	if err != nil {
+		return "", err
+	}
+
+	path, err := config.ProfilePath(configDir, strings.TrimSpace(name))
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	return path, nil
+}
@@ -0,0 +1,334 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/manifoldco/promptui"
+
+	"rdpctl/inherit"
+	"rdpctl/model"
+	"rdpctl/session"
+	"rdpctl/vault"
+)
+
+// DefaultStagger is the delay between launching workspace members when the workspace sets none.
+var DefaultStagger = 2 * time.Second
+
+// Workspace menu entries.
+const (
+	workspaceOpen   = "Open workspace"
+	workspaceCreate = "Create workspace"
+	workspaceDelete = "Delete workspace"
+	workspaceBack   = "Back"
+)
+
+// WorkspacesMenu displays the workspace submenu and saves the vault after changes.
+func WorkspacesMenu(v *model.Vault, masterPassword string, vaultPath string) error {
+	prompt := promptui.Select{
+		Label: "Workspaces",
+		Items: []string{workspaceOpen, workspaceCreate, workspaceDelete, workspaceBack},
+	}
+
+	_, choice, err := prompt.Run()
+	if err != nil {
+		return err
+	}
+
+	switch choice {
+	case workspaceOpen:
+		ws, err := SelectWorkspace(v)
+		if err != nil {
+			return err
+		}
+		openErr := OpenWorkspace(v, ws, WorkspaceStagger(ws), func(c *model.Connection, sess *session.Session) {
+			RecordLaunch(vaultPath, v, c, sess)
+		})
+		if err := vault.SaveVault(vaultPath, v, masterPassword); err != nil {
+			return err
+		}
+		return openErr
+	case workspaceCreate:
+		if err := CreateWorkspace(v); err != nil {
+			return err
+		}
+		return vault.SaveVault(vaultPath, v, masterPassword)
+	case workspaceDelete:
+		if err := DeleteWorkspace(v); err != nil {
+			return err
+		}
+		return vault.SaveVault(vaultPath, v, masterPassword)
+	}
+	return nil
+}
+
+// WorkspaceStagger returns the configured delay between member launches of ws.
+func WorkspaceStagger(ws *model.Workspace) time.Duration {
+	if ws.StaggerSeconds != nil {
+		return time.Duration(*ws.StaggerSeconds) * time.Second
+	}
+	return DefaultStagger
+}
+
+// OpenWorkspace launches every member of ws in the background, waiting stagger between launches.
+// Passwords that are not stored are collected up front so the launches run unattended.
+// It reports each failed member and returns an error if any launch failed. launched, if non-nil,
+// is called with each connection that was started and its session.
+func OpenWorkspace(v *model.Vault, ws *model.Workspace, stagger time.Duration, launched func(*model.Connection, *session.Session)) error {
+	fmt.Printf("\n--- Opening workspace '%s' ---\n", ws.Name)
+
+	type launch struct {
+		source   *model.Connection
+		conn     model.Connection
+		password string
+	}
+
+	var launches []launch
+	var failures []string
+
+	for _, member := range ws.Members {
+		conn := findConnectionByID(v, member.ConnectionID)
+		if conn == nil {
+			failures = append(failures, fmt.Sprintf("%s: connection no longer exists", member.ConnectionID))
+			continue
+		}
+
+		// Member overrides apply on top of the settings the connection inherits
+		effective, _ := inherit.Effective(v, conn, nil)
+		password, err := connectionPassword(&effective)
+		if err != nil {
+			return err
+		}
+		launches = append(launches, launch{source: conn, conn: applyMemberOverrides(effective, member), password: password})
+	}
+
+	store, err := session.DefaultStore()
+	if err != nil {
+		return err
+	}
+
+	started := 0
+	for i := range launches {
+		l := &launches[i]
+		if i > 0 && stagger > 0 {
+			time.Sleep(stagger)
+		}
+
+		if err := wakeIfConfigured(&l.conn); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", l.conn.Name, err))
+			continue
+		}
+		if err := ShowTOTP(&l.conn); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", l.conn.Name, err))
+			continue
+		}
+
+		sess, err := store.Launch(&l.conn, l.password, v.Variables)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", l.conn.Name, err))
+			continue
+		}
+		fmt.Printf("Started %s (PID %d)\n", l.conn.Name, sess.PID)
+		if launched != nil {
+			launched(l.source, sess)
+		}
+		started++
+	}
+
+	fmt.Printf("Launched %d of %d connections.\n", started, len(ws.Members))
+	if len(failures) > 0 {
+		fmt.Println("Failed launches:")
+		for _, f := range failures {
+			fmt.Printf("  - %s\n", f)
+		}
+		return fmt.Errorf("%d of %d launches failed", len(failures), len(ws.Members))
+	}
+	return nil
+}
+
+// applyMemberOverrides returns a copy of c with the member's monitor, size and extra arguments applied.
+func applyMemberOverrides(c model.Connection, m model.WorkspaceMember) model.Connection {
+	args := append([]string{}, c.ExtraArgs...)
+	if m.Monitor != "" {
+		args = append(args, "/multimon", fmt.Sprintf("/monitors:%s", m.Monitor))
+	}
+	if m.Size != "" {
+		args = append(args, fmt.Sprintf("/size:%s", m.Size))
+	}
+	args = append(args, m.ExtraArgs...)
+	c.ExtraArgs = args
+	return c
+}
+
+// SelectWorkspace prompts the user to select a workspace from the vault.
+func SelectWorkspace(v *model.Vault) (*model.Workspace, error) {
+	if len(v.Workspaces) == 0 {
+		return nil, fmt.Errorf("no workspaces available. Please create one first.")
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "\U000027A4 {{ .Name | green }} ({{ len .Members }} connections)",
+		Inactive: "  {{ .Name | faint }} ({{ len .Members }} connections)",
+		Selected: "{{ .Name | green }}",
+	}
+
+	prompt := promptui.Select{
+		Label:     "Select workspace",
+		Items:     v.Workspaces,
+		Templates: templates,
+		Size:      PageSize,
+	}
+
+	i, _, err := prompt.Run()
+	if err != nil {
+		return nil, fmt.Errorf("workspace selection failed %w", err)
+	}
+
+	return &v.Workspaces[i], nil
+}
+
+// CreateWorkspace guides the user through building a new workspace from existing connections.
+func CreateWorkspace(v *model.Vault) error {
+	fmt.Println("\n--- Create Workspace ---")
+
+	if len(v.Connections) == 0 {
+		return fmt.Errorf("no connections available. Please add a new host first.")
+	}
+
+	namePrompt := promptui.Prompt{
+		Label:    "Workspace name",
+		Validate: requireInput,
+	}
+	name, err := namePrompt.Run()
+	if err != nil {
+		return fmt.Errorf("prompt failed: %w", err)
+	}
+
+	ws := model.Workspace{
+		ID:   uuid.New().String(),
+		Name: name,
+	}
+
+	for {
+		addPrompt := promptui.Select{
+			Label: fmt.Sprintf("Workspace has %d connections", len(ws.Members)),
+			Items: []string{"Add a connection", "Done"},
+		}
+		_, choice, err := addPrompt.Run()
+		if err != nil {
+			return fmt.Errorf("prompt failed: %w", err)
+		}
+		if choice == "Done" {
+			break
+		}
+
+		conn, err := SelectConnection(v)
+		if err != nil {
+			return err
+		}
+
+		member, err := promptMemberOverrides(conn)
+		if err != nil {
+			return err
+		}
+		ws.Members = append(ws.Members, member)
+	}
+
+	if len(ws.Members) == 0 {
+		return fmt.Errorf("workspace must contain at least one connection")
+	}
+
+	staggerPrompt := promptui.Prompt{
+		Label: fmt.Sprintf("Seconds between launches (blank for %d, 0 for none)", int(DefaultStagger.Seconds())),
+		Validate: func(input string) error {
+			if strings.TrimSpace(input) == "" {
+				return nil
+			}
+			if n, err := strconv.Atoi(strings.TrimSpace(input)); err != nil || n < 0 {
+				return fmt.Errorf("enter a non-negative number of seconds")
+			}
+			return nil
+		},
+	}
+	staggerInput, err := staggerPrompt.Run()
+	if err != nil {
+		return fmt.Errorf("prompt failed: %w", err)
+	}
+	if strings.TrimSpace(staggerInput) != "" {
+		seconds, _ := strconv.Atoi(strings.TrimSpace(staggerInput))
+		ws.StaggerSeconds = &seconds
+	}
+
+	v.Workspaces = append(v.Workspaces, ws)
+	fmt.Printf("Workspace '%s' created with %d connections.\n", ws.Name, len(ws.Members))
+	return nil
+}
+
+// promptMemberOverrides asks for the optional per-member launch overrides of a workspace member.
+func promptMemberOverrides(c *model.Connection) (model.WorkspaceMember, error) {
+	member := model.WorkspaceMember{ConnectionID: c.ID}
+
+	monitorPrompt := promptui.Prompt{
+		Label: fmt.Sprintf("Monitor(s) for %s (optional, e.g. 1 or 0,1)", c.Name),
+	}
+	monitor, err := monitorPrompt.Run()
+	if err != nil {
+		return member, fmt.Errorf("prompt failed: %w", err)
+	}
+	member.Monitor = strings.TrimSpace(monitor)
+
+	sizePrompt := promptui.Prompt{
+		Label: fmt.Sprintf("Window size for %s (optional, e.g. 1920x1080)", c.Name),
+	}
+	size, err := sizePrompt.Run()
+	if err != nil {
+		return member, fmt.Errorf("prompt failed: %w", err)
+	}
+	member.Size = strings.TrimSpace(size)
+
+	return member, nil
+}
+
+// DeleteWorkspace guides the user through deleting a workspace. Its connections are kept.
+func DeleteWorkspace(v *model.Vault) error {
+	fmt.Println("\n--- Delete Workspace ---")
+
+	ws, err := SelectWorkspace(v)
+	if err != nil {
+		return err
+	}
+
+	confirmPrompt := promptui.Prompt{
+		Label:     fmt.Sprintf("Are you sure you want to delete workspace '%s'? (yes/no)", ws.Name),
+		IsConfirm: true,
+	}
+	if _, err := confirmPrompt.Run(); err != nil {
+		fmt.Println("Deletion cancelled.")
+		return nil
+	}
+
+	for i := range v.Workspaces {
+		if v.Workspaces[i].ID == ws.ID {
+			name := ws.Name
+			v.Workspaces = append(v.Workspaces[:i], v.Workspaces[i+1:]...)
+			fmt.Printf("Workspace '%s' deleted successfully!\n", name)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("failed to find workspace with ID %s to delete", ws.ID)
+}
+
+// findConnectionByID returns the connection in v with the given ID, or nil.
+func findConnectionByID(v *model.Vault, id string) *model.Connection {
+	for i := range v.Connections {
+		if v.Connections[i].ID == id {
+			return &v.Connections[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package usage
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"rdpctl/model"
+)
+
+// HalfLife is how long it takes a connect to count for half as much in the frequency score.
+const HalfLife = 14 * 24 * time.Hour
+
+// SortOrder selects how connections are ordered in the selector and in listings.
+type SortOrder string
+
+const (
+	SortVault    SortOrder = ""         // Vault (insertion) order
+	SortRecent   SortOrder = "recent"   // Most recently connected first
+	SortFrequent SortOrder = "frequent" // Highest decayed connect frequency first
+	SortName     SortOrder = "name"     // Alphabetical by name
+	SortHost     SortOrder = "host"     // Alphabetical by host
+)
+
+// SortOrders lists the selectable sort orders.
+var SortOrders = []SortOrder{SortRecent, SortFrequent, SortName, SortHost}
+
+// ParseSortOrder validates a sort order name. An empty string selects vault order.
+func ParseSortOrder(s string) (SortOrder, error) {
+	order := SortOrder(strings.ToLower(strings.TrimSpace(s)))
+	if order == SortVault {
+		return order, nil
+	}
+	for _, o := range SortOrders {
+		if order == o {
+			return order, nil
+		}
+	}
+	return SortVault, fmt.Errorf("unknown sort order %q (expected recent, frequent, name or host)", s)
+}
+
+// Record notes that c was connected to at now.
+func Record(c *model.Connection, now time.Time) {
+	c.FrequencyScore = Frequency(c, now) + 1
+	c.ConnectCount++
+	c.LastConnectedAt = now
+}
+
+// Frequency returns the connection's connect count with each connect decayed by its age.
+func Frequency(c *model.Connection, now time.Time) float64 {
+	if c.LastConnectedAt.IsZero() {
+		return 0
+	}
+	age := now.Sub(c.LastConnectedAt)
+	if age < 0 {
+		age = 0
+	}
+	return c.FrequencyScore * math.Pow(0.5, float64(age)/float64(HalfLife))
+}
+
+// Sorted returns pointers to the connections in conns, ordered by order. conns itself is not reordered.
+func Sorted(conns []model.Connection, order SortOrder, now time.Time) []*model.Connection {
+	sorted := make([]*model.Connection, len(conns))
+	for i := range conns {
+		sorted[i] = &conns[i]
+	}
+
+	var less func(a, b *model.Connection) bool
+	switch order {
+	case SortRecent:
+		less = func(a, b *model.Connection) bool { return a.LastConnectedAt.After(b.LastConnectedAt) }
+	case SortFrequent:
+		less = func(a, b *model.Connection) bool { return Frequency(a, now) > Frequency(b, now) }
+	case SortName:
+		less = func(a, b *model.Connection) bool { return strings.ToLower(a.Name) < strings.ToLower(b.Name) }
+	case SortHost:
+		less = func(a, b *model.Connection) bool { return strings.ToLower(a.Host) < strings.ToLower(b.Host) }
+	default:
+		return sorted
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool { return less(sorted[i], sorted[j]) })
+	return sorted
+}
+
+// Recent returns up to n connections that have been connected to, most recent first.
+func Recent(conns []model.Connection, n int) []*model.Connection {
+	var recent []*model.Connection
+	for _, c := range Sorted(conns, SortRecent, time.Now()) {
+		if len(recent) == n || c.LastConnectedAt.IsZero() {
+			break
+		}
+		recent = append(recent, c)
+	}
+	return recent
+}
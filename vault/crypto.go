@@ -4,6 +4,7 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"crypto/sha256"
 	"fmt"
 	"io"
 
@@ -17,7 +18,8 @@ const (
 )
 
 // DeriveKey derives a cryptographic key from the password and salt using Argon2id.
-func DeriveKey(password string, salt []byte) ([]byte, error) {
+// If keyfileHash is not nil, the Argon2id output is combined with it, so both are needed.
+func DeriveKey(password string, salt, keyfileHash []byte) ([]byte, error) {
 	if len(salt) != saltLength {
 		return nil, fmt.Errorf("salt must be %d bytes long", saltLength)
 	}
@@ -25,17 +27,21 @@ func DeriveKey(password string, salt []byte) ([]byte, error) {
 	// Using recommended parameters for Argon2id
 	// time: 1, memory: 64MB, threads: 4
 	key := argon2.IDKey([]byte(password), salt, 1, 64*1024, 4, keyLength)
+	if keyfileHash != nil {
+		combined := sha256.Sum256(append(key, keyfileHash...))
+		key = combined[:]
+	}
 	return key, nil
 }
 
-// EncryptVault encrypts the JSON data using AES-256-GCM with a master password.
-func EncryptVault(jsonData []byte, password string) (salt, nonce, ciphertext []byte, err error) {
+// EncryptVault encrypts the JSON data using AES-256-GCM with a master password and optional keyfile hash.
+func EncryptVault(jsonData []byte, password string, keyfileHash []byte) (salt, nonce, ciphertext []byte, err error) {
 	salt = make([]byte, saltLength)
 	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
 		return nil, nil, nil, fmt.Errorf("failed to generate salt: %w", err)
 	}
 
-	key, err := DeriveKey(password, salt)
+	key, err := DeriveKey(password, salt, keyfileHash)
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("failed to derive key: %w", err)
 	}
@@ -59,9 +65,9 @@ func EncryptVault(jsonData []byte, password string) (salt, nonce, ciphertext []b
 	return salt, nonce, ciphertext, nil
 }
 
-// DecryptVault decrypts the ciphertext using AES-256-GCM with the master password.
-func DecryptVault(salt, nonce, ciphertext []byte, password string) ([]byte, error) {
-	key, err := DeriveKey(password, salt)
+// DecryptVault decrypts the ciphertext using AES-256-GCM with the master password and optional keyfile hash.
+func DecryptVault(salt, nonce, ciphertext []byte, password string, keyfileHash []byte) ([]byte, error) {
+	key, err := DeriveKey(password, salt, keyfileHash)
 	if err != nil {
 		return nil, fmt.Errorf("failed to derive key: %w", err)
 	}
@@ -78,7 +84,7 @@ func DecryptVault(salt, nonce, ciphertext []byte, password string) ([]byte, erro
 
 	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt vault: %w", err)
+		return nil, ErrWrongPassword
 	}
 
 	return plaintext, nil
@@ -1,7 +1,7 @@
 package vault
 
 import (
-
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -13,59 +13,70 @@ import (
 const (
 	magicBytes = "RDP1"
 	version = 1
+	keyfileVersion = 2 // Version 1 plus a keyfile check value after the version byte
 	magicLen = 4
 	versionLen = 1
 )
 
-// ReadVaultFile reads an encrypted vault file from disk.
-func ReadVaultFile(path string) (salt, nonce, ciphertext []byte, err error) {
+// ReadVaultFile reads an encrypted vault file from disk. keyfileCheck is nil unless the
+// vault requires a keyfile.
+func ReadVaultFile(path string) (keyfileCheck, salt, nonce, ciphertext []byte, err error) {
 	file, err := os.Open(path)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("failed to open vault file: %w", err)
+		return nil, nil, nil, nil, fmt.Errorf("failed to open vault file: %w", err)
 	}
 	defer file.Close()
 
 	// Read Magic Bytes
 	readMagic := make([]byte, magicLen)
 	if _, err := io.ReadFull(file, readMagic); err != nil {
-		return nil, nil, nil, fmt.Errorf("failed to read magic bytes: %w", err)
+		return nil, nil, nil, nil, fmt.Errorf("failed to read magic bytes: %w", err)
 	}
 	if string(readMagic) != magicBytes {
-		return nil, nil, nil, fmt.Errorf("invalid vault file magic bytes")
+		return nil, nil, nil, nil, fmt.Errorf("invalid vault file magic bytes")
 	}
 
 	// Read Version
 	readVersion := make([]byte, versionLen)
 	if _, err := io.ReadFull(file, readVersion); err != nil {
-		return nil, nil, nil, fmt.Errorf("failed to read version: %w", err)
+		return nil, nil, nil, nil, fmt.Errorf("failed to read version: %w", err)
+	}
+	if readVersion[0] != version && readVersion[0] != keyfileVersion {
+		return nil, nil, nil, nil, fmt.Errorf("unsupported vault version: %d", readVersion[0])
 	}
-	if readVersion[0] != version {
-		return nil, nil, nil, fmt.Errorf("unsupported vault version: %d", readVersion[0])
+
+	// Read Keyfile Check
+	if readVersion[0] == keyfileVersion {
+		keyfileCheck = make([]byte, keyfileCheckLength)
+		if _, err := io.ReadFull(file, keyfileCheck); err != nil {
+			return nil, nil, nil, nil, fmt.Errorf("failed to read keyfile check: %w", err)
+		}
 	}
 
 	// Read Salt
 	salt = make([]byte, saltLength)
 	if _, err := io.ReadFull(file, salt); err != nil {
-		return nil, nil, nil, fmt.Errorf("failed to read salt: %w", err)
+		return nil, nil, nil, nil, fmt.Errorf("failed to read salt: %w", err)
 	}
 
 	// Read Nonce
 	nonce = make([]byte, nonceLength)
 	if _, err := io.ReadFull(file, nonce); err != nil {
-		return nil, nil, nil, fmt.Errorf("failed to read nonce: %w", err)
+		return nil, nil, nil, nil, fmt.Errorf("failed to read nonce: %w", err)
 	}
 
 	// Read Ciphertext
 	ciphertext, err = io.ReadAll(file)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("failed to read ciphertext: %w", err)
+		return nil, nil, nil, nil, fmt.Errorf("failed to read ciphertext: %w", err)
 	}
 
-	return salt, nonce, ciphertext, nil
+	return keyfileCheck, salt, nonce, ciphertext, nil
 }
 
-// WriteVaultFile writes an encrypted vault file to disk.
-func WriteVaultFile(path string, salt, nonce, ciphertext []byte) error {
+// WriteVaultFile writes an encrypted vault file to disk. A non-nil keyfileCheck marks the vault
+// as requiring a keyfile.
+func WriteVaultFile(path string, keyfileCheck, salt, nonce, ciphertext []byte) error {
 	file, err := os.Create(path)
 	if err != nil {
 		return fmt.Errorf("failed to create vault file: %w", err)
@@ -78,10 +89,21 @@ func WriteVaultFile(path string, salt, nonce, ciphertext []byte) error {
 	}
 
 	// Write Version
-	if _, err := file.Write([]byte{version}); err != nil {
+	fileVersion := byte(version)
+	if keyfileCheck != nil {
+		fileVersion = keyfileVersion
+	}
+	if _, err := file.Write([]byte{fileVersion}); err != nil {
 		return fmt.Errorf("failed to write version: %w", err)
 	}
 
+	// Write Keyfile Check
+	if keyfileCheck != nil {
+		if _, err := file.Write(keyfileCheck); err != nil {
+			return fmt.Errorf("failed to write keyfile check: %w", err)
+		}
+	}
+
 	// Write Salt
 	if _, err := file.Write(salt); err != nil {
 		return fmt.Errorf("failed to write salt: %w", err)
@@ -100,14 +122,23 @@ func WriteVaultFile(path string, salt, nonce, ciphertext []byte) error {
 	return nil
 }
 
-// DecryptAndUnmarshalVault reads, decrypts, and unmarshals the vault from disk.
-func DecryptAndUnmarshalVault(path string, password string) (*model.Vault, error) {
-	salt, nonce, ciphertext, err := ReadVaultFile(path)
+// DecryptAndUnmarshalVault reads, decrypts, and unmarshals the vault from disk. keyfileHash is
+// only used if the vault requires a keyfile.
+func DecryptAndUnmarshalVault(path string, password string, keyfileHash []byte) (*model.Vault, error) {
+	keyfileCheck, salt, nonce, ciphertext, err := ReadVaultFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read vault file: %w", err)
 	}
 
-	plaintext, err := DecryptVault(salt, nonce, ciphertext, password)
+	if keyfileCheck == nil {
+		keyfileHash = nil
+	} else if keyfileHash == nil {
+		return nil, ErrKeyfileRequired
+	} else if !bytes.Equal(keyfileCheck, checkKeyfile(keyfileHash)) {
+		return nil, ErrKeyfileMismatch
+	}
+
+	plaintext, err := DecryptVault(salt, nonce, ciphertext, password, keyfileHash)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decrypt vault: %w", err)
 	}
@@ -116,34 +147,42 @@ func DecryptAndUnmarshalVault(path string, password string) (*model.Vault, error
 	if err := json.Unmarshal(plaintext, v); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal vault JSON: %w", err)
 	}
+	v.KeyfileHash = keyfileHash
 
 	return v, nil
 }
 
-// MarshalAndEncryptVault marshals the vault, encrypts it, and writes it to disk.
+// MarshalAndEncryptVault marshals the vault, encrypts it, and writes it to disk. The vault
+// requires a keyfile if v.KeyfileHash is set.
 func MarshalAndEncryptVault(path string, v *model.Vault, password string) error {
 	jsonData, err := json.Marshal(v)
 	if err != nil {
 		return fmt.Errorf("failed to marshal vault: %w", err)
 	}
 
-	salt, nonce, ciphertext, err := EncryptVault(jsonData, password)
+	salt, nonce, ciphertext, err := EncryptVault(jsonData, password, v.KeyfileHash)
 	if err != nil {
 		return fmt.Errorf("failed to encrypt vault: %w", err)
 	}
 
-	if err := WriteVaultFile(path, salt, nonce, ciphertext); err != nil {
+	var keyfileCheck []byte
+	if v.KeyfileHash != nil {
+		keyfileCheck = checkKeyfile(v.KeyfileHash)
+	}
+	if err := WriteVaultFile(path, keyfileCheck, salt, nonce, ciphertext); err != nil {
 		return fmt.Errorf("failed to write vault file: %w", err)
 	}
 
 	return nil
 }
 
-// CreateAndSaveNewVaultFile creates a new, empty vault and saves it to disk.
-func CreateAndSaveNewVaultFile(path string, password string) (*model.Vault, error) {
+// CreateAndSaveNewVaultFile creates a new, empty vault and saves it to disk. If keyfileHash is
+// not nil, the vault requires that keyfile.
+func CreateAndSaveNewVaultFile(path string, password string, keyfileHash []byte) (*model.Vault, error) {
 	v := &model.Vault{
 		Version:     version,
 		Connections: []model.Connection{},
+		KeyfileHash: keyfileHash,
 	}
 
 	if err := MarshalAndEncryptVault(path, v, password); err != nil {
@@ -0,0 +1,87 @@
+package vault
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const (
+	keyfileCheckLength = 8
+	keyfileSize        = 32 // Random bytes in a generated keyfile
+)
+
+// Keyfile errors returned by LoadVault.
+var (
+	ErrKeyfileRequired = errors.New("this vault requires a keyfile")
+	ErrKeyfileMismatch = errors.New("the keyfile does not match this vault")
+)
+
+// ErrWrongPassword is returned by LoadVault when the vault does not decrypt with the given master
+// password (and keyfile). A damaged vault file fails the same way.
+var ErrWrongPassword = errors.New("incorrect master password")
+
+// HashKeyfile returns the SHA-256 hash of the keyfile at path. Any file can serve as a keyfile.
+func HashKeyfile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keyfile: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("keyfile %s is empty", path)
+	}
+	hash := sha256.Sum256(data)
+	return hash[:], nil
+}
+
+// GenerateKeyfile writes a new keyfile of random data to path. It never overwrites an existing file.
+func GenerateKeyfile(path string) error {
+	key := make([]byte, keyfileSize)
+	if _, err := rand.Read(key); err != nil {
+		return fmt.Errorf("failed to generate keyfile: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0400)
+	if err != nil {
+		return fmt.Errorf("failed to create keyfile: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := fmt.Fprintln(file, hex.EncodeToString(key)); err != nil {
+		return fmt.Errorf("failed to write keyfile: %w", err)
+	}
+	return nil
+}
+
+// RequiresKeyfile reports whether the vault at path can only be unlocked with a keyfile.
+func RequiresKeyfile(path string) (bool, error) {
+	keyfileCheck, _, _, _, err := ReadVaultFile(path)
+	if err != nil {
+		return false, err
+	}
+	return keyfileCheck != nil, nil
+}
+
+// CheckKeyfile returns ErrKeyfileMismatch if the vault at path requires a keyfile other than the
+// one with the given hash. It does not need the master password.
+func CheckKeyfile(path string, keyfileHash []byte) error {
+	keyfileCheck, _, _, _, err := ReadVaultFile(path)
+	if err != nil {
+		return err
+	}
+	if keyfileCheck != nil && !bytes.Equal(keyfileCheck, checkKeyfile(keyfileHash)) {
+		return ErrKeyfileMismatch
+	}
+	return nil
+}
+
+// checkKeyfile derives the short value stored in the vault header that tells a wrong keyfile
+// apart from a wrong password.
+func checkKeyfile(keyfileHash []byte) []byte {
+	check := sha256.Sum256(append([]byte("rdpctl keyfile check\x00"), keyfileHash...))
+	return check[:keyfileCheckLength]
+}
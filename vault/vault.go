@@ -3,13 +3,17 @@ package vault
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"rdpctl/model"
 )
 
-// LoadVault loads an existing vault from the specified path using the provided password.
+// LoadVault loads an existing vault from the specified path using the provided password and,
+// for vaults that require one, the hash of their keyfile (see HashKeyfile).
 // It returns the loaded vault and any error encountered.
-func LoadVault(path string, password string) (*model.Vault, error) {
+func LoadVault(path string, password string, keyfileHash []byte) (*model.Vault, error) {
 	// Check if the vault file exists
 	_, err := os.Stat(path)
 	if os.IsNotExist(err) {
@@ -20,16 +24,24 @@ func LoadVault(path string, password string) (*model.Vault, error) {
 	}
 
 	// Decrypt and unmarshal the vault
-	v, err := DecryptAndUnmarshalVault(path, password)
+	v, err := DecryptAndUnmarshalVault(path, password, keyfileHash)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load and decrypt vault: %w", err)
 	}
 	return v, nil
 }
 
+// BackupCount is how many previous versions of the vault file SaveVault keeps as
+// vault.enc.1 (newest) through vault.enc.N. 0 disables backups.
+var BackupCount = 0
+
 // SaveVault encrypts and saves the given vault to the specified path.
 // It returns an error if the operation fails.
 func SaveVault(path string, v *model.Vault, password string) error {
+	if err := rotateBackups(path, BackupCount); err != nil {
+		return fmt.Errorf("failed to back up vault: %w", err)
+	}
+
 	// Marshal and encrypt the vault, then write to file
 	if err := MarshalAndEncryptVault(path, v, password); err != nil {
 		return fmt.Errorf("failed to encrypt and save vault: %w", err)
@@ -37,12 +49,62 @@ func SaveVault(path string, v *model.Vault, password string) error {
 	return nil
 }
 
-// CreateNewVault creates a new empty vault and saves it to the specified path.
+// CreateNewVault creates a new empty vault and saves it to the specified path. If keyfileHash is
+// not nil, unlocking the vault requires that keyfile as well as the password.
 // It returns the newly created vault and any error encountered.
-func CreateNewVault(path string, password string) (*model.Vault, error) {
-	v, err := CreateAndSaveNewVaultFile(path, password)
+func CreateNewVault(path string, password string, keyfileHash []byte) (*model.Vault, error) {
+	v, err := CreateAndSaveNewVaultFile(path, password, keyfileHash)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create and save new vault file: %w", err)
 	}
 	return v, nil
-}
\ No newline at end of file
+}
+
+// RemoveBackups deletes every backup SaveVault has kept of the vault at path, whatever the
+// current BackupCount, and returns how many it deleted. Backups stay protected the way the vault
+// was when they were written, so they must go when that protection is strengthened.
+func RemoveBackups(path string) (int, error) {
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		return 0, err
+	}
+
+	prefix := filepath.Base(path) + "."
+	removed := 0
+	for _, entry := range entries {
+		suffix, ok := strings.CutPrefix(entry.Name(), prefix)
+		if !ok {
+			continue
+		}
+		if _, err := strconv.Atoi(suffix); err != nil {
+			continue
+		}
+		if err := os.Remove(filepath.Join(filepath.Dir(path), entry.Name())); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// rotateBackups shifts path.1 .. path.(count-1) up by one and copies the current vault file to path.1.
+func rotateBackups(path string, count int) error {
+	if count <= 0 {
+		return nil
+	}
+	current, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for i := count - 1; i >= 1; i-- {
+		from := path + "." + strconv.Itoa(i)
+		if err := os.Rename(from, path+"."+strconv.Itoa(i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.WriteFile(path+".1", current, 0600)
+}
@@ -0,0 +1,64 @@
+package wol
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strconv"
+)
+
+var (
+	// DefaultBroadcast is the broadcast address used when a connection configures none.
+	DefaultBroadcast = "255.255.255.255"
+	// DefaultPort is the UDP port used when a connection configures none; 9 is the conventional "discard" port.
+	DefaultPort = 9
+)
+
+// MagicPacket builds a Wake-on-LAN magic packet for the given MAC address:
+// six 0xFF bytes followed by the MAC address repeated sixteen times.
+func MagicPacket(mac string) ([]byte, error) {
+	hw, err := net.ParseMAC(mac)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MAC address %q: %w", mac, err)
+	}
+	if len(hw) != 6 {
+		return nil, fmt.Errorf("invalid MAC address %q: expected 6 bytes, got %d", mac, len(hw))
+	}
+
+	packet := bytes.Repeat([]byte{0xFF}, 6)
+	packet = append(packet, bytes.Repeat(hw, 16)...)
+	return packet, nil
+}
+
+// Send broadcasts a magic packet for mac to the given broadcast address and UDP port.
+// Empty broadcast and zero port fall back to DefaultBroadcast and DefaultPort.
+func Send(mac, broadcast string, port int) error {
+	packet, err := MagicPacket(mac)
+	if err != nil {
+		return err
+	}
+
+	if broadcast == "" {
+		broadcast = DefaultBroadcast
+	}
+	if port == 0 {
+		port = DefaultPort
+	}
+
+	addr, err := net.ResolveUDPAddr("udp4", net.JoinHostPort(broadcast, strconv.Itoa(port)))
+	if err != nil {
+		return fmt.Errorf("failed to resolve broadcast address: %w", err)
+	}
+
+	conn, err := net.DialUDP("udp4", nil, addr)
+	if err != nil {
+		return fmt.Errorf("failed to open UDP socket: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write(packet); err != nil {
+		return fmt.Errorf("failed to send magic packet: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package wol
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// DefaultWaitTimeout is how long callers wait for a woken host by default.
+var DefaultWaitTimeout = 2 * time.Minute
+
+const (
+	pollInterval = 3 * time.Second
+	dialTimeout  = 2 * time.Second
+)
+
+// WaitForPort polls the TCP address until it accepts a connection or the timeout expires.
+// The progress callback, if non-nil, is invoked after every failed attempt with the elapsed time.
+func WaitForPort(address string, timeout time.Duration, progress func(elapsed time.Duration)) error {
+	start := time.Now()
+	deadline := start.Add(timeout)
+
+	for {
+		conn, err := net.DialTimeout("tcp", address, dialTimeout)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+
+		if time.Now().Add(pollInterval).After(deadline) {
+			return fmt.Errorf("%s did not become reachable within %s: %w", address, timeout, err)
+		}
+
+		if progress != nil {
+			progress(time.Since(start))
+		}
+		time.Sleep(pollInterval)
+	}
+}